@@ -0,0 +1,254 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry 缓冲区中的一条记录
+type Entry struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Ring 基于磁盘的有界缓冲区，按写入顺序保存带时间戳的 JSON 记录。
+// 超出大小限制时丢弃最旧的记录，超过保存时长的记录在压缩与回放时丢弃。
+type Ring struct {
+	mu sync.Mutex
+	// replayMu 保证同一时间只有一个回放
+	replayMu sync.Mutex
+	path     string
+	maxBytes int64
+	maxAge   time.Duration
+	size     int64
+	now      func() time.Time
+}
+
+// Open 打开（或创建）path 处的缓冲文件
+func Open(path string, maxBytes int64, maxAge time.Duration) (*Ring, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create buffer directory: %v", err)
+	}
+	r := &Ring{
+		path:     path,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		now:      time.Now,
+	}
+	st, err := os.Stat(path)
+	if err == nil {
+		r.size = st.Size()
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to stat buffer file: %v", err)
+	}
+	return r, nil
+}
+
+// Append 追加一条记录，data 必须是合法的 JSON
+func (r *Ring) Append(ts time.Time, data []byte) error {
+	line, err := json.Marshal(Entry{Timestamp: ts, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal buffer entry: %v", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxBytes > 0 && int64(len(line)) > r.maxBytes {
+		return fmt.Errorf("entry of %d bytes exceeds buffer size", len(line))
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open buffer file: %v", err)
+	}
+	n, err := f.Write(line)
+	r.size += int64(n)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write buffer file: %v", err)
+	}
+
+	if r.maxBytes > 0 && r.size > r.maxBytes {
+		// 压缩到上限的 3/4，避免每次追加都重写文件
+		return r.compact(r.maxBytes * 3 / 4)
+	}
+	return nil
+}
+
+// SetLimits 修改大小上限与保存时长，下次追加、压缩或回放时生效
+func (r *Ring) SetLimits(maxBytes int64, maxAge time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxBytes, r.maxAge = maxBytes, maxAge
+}
+
+// Len 返回缓冲区中的记录数
+func (r *Ring) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries, _ := r.read()
+	return len(entries)
+}
+
+// replayBatchMin 回放时每发送一批记录后从缓冲文件中移除，每批至少这么多条
+const replayBatchMin = 16
+
+// Replay 按写入顺序依次将记录交给 send，每发送一批就从缓冲文件中移除这些记录。
+// send 返回错误或进程在回放中途退出时，未确认移除的记录保留到下次回放，
+// 因此异常退出后最多重复发送一批记录，而不会丢失。
+func (r *Ring) Replay(send func(Entry) error) (int, error) {
+	r.replayMu.Lock()
+	defer r.replayMu.Unlock()
+
+	r.mu.Lock()
+	entries, err := r.read()
+	r.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	live := r.dropExpired(entries)
+	if err := r.remove(entries[:len(entries)-len(live)]); err != nil {
+		return 0, err
+	}
+
+	// 每次移除都要重写整个文件，批大小随记录数增加，回放的总写入量不超过文件大小的数倍
+	batchSize := max(replayBatchMin, len(live)/8)
+	for start := 0; start < len(live); start += batchSize {
+		batch := live[start:min(start+batchSize, len(live))]
+		for i, entry := range batch {
+			if err := send(entry); err != nil {
+				if rerr := r.remove(batch[:i]); rerr != nil {
+					err = fmt.Errorf("%w; %v", err, rerr)
+				}
+				return start + i, err
+			}
+		}
+		if err := r.remove(batch); err != nil {
+			return start + len(batch), err
+		}
+	}
+	return len(live), nil
+}
+
+// remove 从缓冲文件中移除已发送的记录。回放期间 Append 可能追加了新记录，
+// 或压缩时丢弃了部分旧记录，因此按内容匹配而不是按位置截断。
+func (r *Ring) remove(sent []Entry) error {
+	if len(sent) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := make(map[string]int, len(sent))
+	for _, entry := range sent {
+		pending[entryKey(entry)]++
+	}
+	current, err := r.read()
+	if err != nil {
+		return err
+	}
+	kept := current[:0]
+	for _, entry := range current {
+		if key := entryKey(entry); pending[key] > 0 {
+			pending[key]--
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return r.write(kept)
+}
+
+func entryKey(e Entry) string {
+	return e.Timestamp.Format(time.RFC3339Nano) + "\x00" + string(e.Data)
+}
+
+// compact 丢弃过期记录与最旧的记录，使文件大小不超过 limit
+func (r *Ring) compact(limit int64) error {
+	entries, err := r.read()
+	if err != nil {
+		return err
+	}
+	return r.write(r.trim(r.dropExpired(entries), limit))
+}
+
+func (r *Ring) dropExpired(entries []Entry) []Entry {
+	if r.maxAge <= 0 {
+		return entries
+	}
+	cutoff := r.now().Add(-r.maxAge)
+	for i, entry := range entries {
+		if !entry.Timestamp.Before(cutoff) {
+			return entries[i:]
+		}
+	}
+	return nil
+}
+
+// trim 从最旧的记录开始丢弃，直到编码后的总大小不超过 limit
+func (r *Ring) trim(entries []Entry, limit int64) []Entry {
+	if limit <= 0 {
+		return entries
+	}
+	var total int64
+	for i := len(entries) - 1; i >= 0; i-- {
+		line, _ := json.Marshal(entries[i])
+		total += int64(len(line)) + 1
+		if total > limit {
+			return entries[i+1:]
+		}
+	}
+	return entries
+}
+
+func (r *Ring) read() ([]Entry, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read buffer file: %v", err)
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		// 跳过异常退出时可能写了一半的行
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// write 以临时文件加重命名的方式整体替换缓冲文件
+func (r *Ring) write(entries []Entry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal buffer entry: %v", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write buffer file: %v", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to replace buffer file: %v", err)
+	}
+	r.size = int64(buf.Len())
+	return nil
+}
@@ -0,0 +1,192 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestRing(t *testing.T, maxBytes int64, maxAge time.Duration) *Ring {
+	t.Helper()
+	r, err := Open(filepath.Join(t.TempDir(), "buffer", "reports.jsonl"), maxBytes, maxAge)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return r
+}
+
+func TestRingReplayInOrder(t *testing.T) {
+	r := openTestRing(t, 0, 0)
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := r.Append(base.Add(time.Duration(i)*time.Second), []byte(fmt.Sprintf(`{"seq":%d}`, i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	sent, err := r.Replay(func(e Entry) error {
+		got = append(got, string(e.Data))
+		return nil
+	})
+	if err != nil || sent != 5 {
+		t.Fatalf("Replay = %d, %v", sent, err)
+	}
+	for i, data := range got {
+		if want := fmt.Sprintf(`{"seq":%d}`, i); data != want {
+			t.Errorf("entry %d = %s, want %s", i, data, want)
+		}
+	}
+	if n := r.Len(); n != 0 {
+		t.Errorf("Len after replay = %d, want 0", n)
+	}
+}
+
+func TestRingReplayFailureKeepsRemaining(t *testing.T) {
+	r := openTestRing(t, 0, 0)
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		r.Append(now, []byte(fmt.Sprintf(`{"seq":%d}`, i)))
+	}
+
+	sent, err := r.Replay(func(e Entry) error {
+		if string(e.Data) == `{"seq":2}` {
+			return errors.New("connection lost")
+		}
+		return nil
+	})
+	if err == nil || sent != 2 {
+		t.Fatalf("Replay = %d, %v; want 2 and an error", sent, err)
+	}
+	r.Append(now, []byte(`{"seq":4}`))
+
+	var got []string
+	r.Replay(func(e Entry) error {
+		got = append(got, string(e.Data))
+		return nil
+	})
+	want := []string{`{"seq":2}`, `{"seq":3}`, `{"seq":4}`}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("second replay = %v, want %v", got, want)
+	}
+}
+
+func TestRingReplayCrashKeepsUnsent(t *testing.T) {
+	r := openTestRing(t, 0, 0)
+	base := time.Now()
+	for i := 0; i < 40; i++ {
+		r.Append(base.Add(time.Duration(i)*time.Second), []byte(fmt.Sprintf(`{"seq":%d}`, i)))
+	}
+
+	// 发送第 20 条时进程退出：第一批（16 条）已移除，之后的记录都还在文件中
+	func() {
+		defer func() { recover() }()
+		r.Replay(func(e Entry) error {
+			if string(e.Data) == `{"seq":20}` {
+				panic("agent crashed")
+			}
+			return nil
+		})
+	}()
+
+	reopened, err := Open(r.path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	reopened.Replay(func(e Entry) error {
+		got = append(got, string(e.Data))
+		return nil
+	})
+	if len(got) != 24 || got[0] != `{"seq":16}` || got[23] != `{"seq":39}` {
+		t.Errorf("replay after crash = %v, want seq 16..39", got)
+	}
+}
+
+func TestRingReplayKeepsEntriesAppendedMeanwhile(t *testing.T) {
+	r := openTestRing(t, 0, 0)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		r.Append(now.Add(time.Duration(i)*time.Second), []byte(fmt.Sprintf(`{"seq":%d}`, i)))
+	}
+	sent, err := r.Replay(func(e Entry) error {
+		if string(e.Data) == `{"seq":1}` {
+			r.Append(now.Add(time.Minute), []byte(`{"seq":3}`))
+		}
+		return nil
+	})
+	if err != nil || sent != 3 {
+		t.Fatalf("Replay = %d, %v", sent, err)
+	}
+	var got []string
+	r.Replay(func(e Entry) error {
+		got = append(got, string(e.Data))
+		return nil
+	})
+	if len(got) != 1 || got[0] != `{"seq":3}` {
+		t.Errorf("second replay = %v, want only the entry appended during the first", got)
+	}
+}
+
+func TestRingSizeLimitDropsOldest(t *testing.T) {
+	r := openTestRing(t, 1024, 0)
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		if err := r.Append(now, []byte(fmt.Sprintf(`{"seq":"%03d"}`, i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st, err := os.Stat(r.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Size() > 1024 {
+		t.Errorf("buffer file is %d bytes, limit 1024", st.Size())
+	}
+
+	var last string
+	r.Replay(func(e Entry) error {
+		last = string(e.Data)
+		return nil
+	})
+	if last != `{"seq":"099"}` {
+		t.Errorf("newest entry = %s, want seq 099", last)
+	}
+}
+
+func TestRingMaxAge(t *testing.T) {
+	r := openTestRing(t, 0, time.Hour)
+	now := time.Now()
+	r.Append(now.Add(-2*time.Hour), []byte(`{"old":true}`))
+	r.Append(now, []byte(`{"old":false}`))
+
+	var got []string
+	r.Replay(func(e Entry) error {
+		got = append(got, string(e.Data))
+		return nil
+	})
+	if len(got) != 1 || got[0] != `{"old":false}` {
+		t.Errorf("replayed %v, want only the fresh entry", got)
+	}
+}
+
+func TestRingReopenAndTruncatedLine(t *testing.T) {
+	r := openTestRing(t, 0, 0)
+	r.Append(time.Now(), []byte(`{"seq":0}`))
+
+	// 模拟异常退出时写了一半的行
+	f, _ := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND, 0600)
+	f.WriteString(`{"timestamp":"2025-`)
+	f.Close()
+
+	reopened, err := Open(r.path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := reopened.Len(); n != 1 {
+		t.Errorf("Len = %d, want 1", n)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/komari-monitor/komari-agent/server"
 )
 
 // AutoDiscoveryConfig 自动发现配置结构体
@@ -94,7 +95,7 @@ func saveAutoDiscoveryConfig(config *AutoDiscoveryConfig) error {
 func registerWithAutoDiscovery() error {
 	// 构造注册请求
 	requestData := RegisterRequest{
-		Key: flags.AutoDiscoveryKey,
+		Key: flags.Parsed.AutoDiscoveryKey,
 	}
 
 	hostname, _ := os.Hostname()
@@ -105,7 +106,7 @@ func registerWithAutoDiscovery() error {
 	}
 
 	// 构造请求URL
-	endpoint := flags.Endpoint
+	endpoint := flags.Parsed.Endpoint
 	if len(endpoint) > 0 && endpoint[len(endpoint)-1] == '/' {
 		endpoint = endpoint[:len(endpoint)-1]
 	}
@@ -119,16 +120,16 @@ func registerWithAutoDiscovery() error {
 
 	// 设置请求头
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", flags.AutoDiscoveryKey))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", flags.Parsed.AutoDiscoveryKey))
 	
 	// 添加Cloudflare Access头部
-	if flags.CFAccessClientID != "" && flags.CFAccessClientSecret != "" {
-		req.Header.Set("CF-Access-Client-Id", flags.CFAccessClientID)
-		req.Header.Set("CF-Access-Client-Secret", flags.CFAccessClientSecret)
+	if flags.Parsed.CFAccessClientID != "" && flags.Parsed.CFAccessClientSecret != "" {
+		req.Header.Set("CF-Access-Client-Id", flags.Parsed.CFAccessClientID)
+		req.Header.Set("CF-Access-Client-Secret", flags.Parsed.CFAccessClientSecret)
 	}
 
 	// 发送请求
-	client := &http.Client{}
+	client := server.HTTPClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send register request: %v", err)
@@ -163,7 +164,7 @@ func registerWithAutoDiscovery() error {
 	}
 
 	// 设置token
-	flags.Token = registerResp.Data.Token
+	flags.Parsed.Token = registerResp.Data.Token
 	log.Printf("Successfully registered with auto-discovery. UUID: %s", registerResp.Data.UUID)
 
 	return nil
@@ -180,7 +181,7 @@ func handleAutoDiscovery() error {
 
 	if config != nil {
 		// 配置文件存在，使用现有token
-		flags.Token = config.Token
+		flags.Parsed.Token = config.Token
 		log.Printf("Using existing auto-discovery token for UUID: %s", config.UUID)
 		return nil
 	}
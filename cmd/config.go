@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const envPrefix = "KOMARI_"
+
+// 配置来源，按优先级从高到低
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceFile    = "file"
+	sourceDefault = "default"
+)
+
+// secretFlags 在打印配置时需要隐藏的参数
+var secretFlags = map[string]struct{}{
+	"token":                   {},
+	"auto-discovery":          {},
+	"cf-access-client-secret": {},
+}
+
+// listSeparators 配置文件中以列表形式书写的参数所使用的分隔符，默认为逗号
+var listSeparators = map[string]string{
+	"include-mountpoint": ";",
+}
+
+var (
+	// cliFlags 启动时在命令行中显式指定的参数
+	cliFlags map[string]struct{}
+	// configSources 记录每个参数最终生效值的来源
+	configSources map[string]string
+)
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect agent configuration",
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the effective configuration with secrets masked",
+	Run: func(cmd *cobra.Command, args []string) {
+		printConfig(cmd.OutOrStdout(), cmd.Root().PersistentFlags(), configSources)
+	},
+}
+
+// loadConfig 按 命令行 > 环境变量 > 配置文件 > 默认值 的优先级填充参数
+func loadConfig(fs *pflag.FlagSet) error {
+	if cliFlags == nil {
+		cliFlags = map[string]struct{}{}
+		fs.VisitAll(func(f *pflag.Flag) {
+			if f.Changed {
+				cliFlags[f.Name] = struct{}{}
+			}
+		})
+	}
+
+	path := flags.Parsed.ConfigFile
+	if _, ok := cliFlags["config"]; !ok {
+		if env, ok := os.LookupEnv(envName("config")); ok {
+			path = env
+		}
+	}
+
+	sources, err := applyConfig(fs, cliFlags, path)
+	if err != nil {
+		return err
+	}
+	configSources = sources
+	return nil
+}
+
+// pinFlag 将参数视为命令行指定，之后重新加载配置时不再覆盖
+func pinFlag(name string) {
+	if cliFlags == nil {
+		cliFlags = map[string]struct{}{}
+	}
+	cliFlags[name] = struct{}{}
+	if configSources != nil {
+		configSources[name] = sourceFlag
+	}
+}
+
+// applyConfig 将环境变量与配置文件中的值写入未在命令行中指定的参数，返回每个参数的来源
+func applyConfig(fs *pflag.FlagSet, cli map[string]struct{}, path string) (map[string]string, error) {
+	fileValues := map[string]string{}
+	if path != "" {
+		var err error
+		fileValues, err = readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key := range fileValues {
+		if fs.Lookup(key) == nil || key == "config" {
+			return nil, fmt.Errorf("unknown key %q in config file %s", key, path)
+		}
+	}
+
+	sources := map[string]string{}
+	var setErr error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if setErr != nil {
+			return
+		}
+		if _, ok := cli[f.Name]; ok {
+			sources[f.Name] = sourceFlag
+			return
+		}
+		if f.Name == "config" {
+			sources[f.Name] = sourceDefault
+			if path != "" {
+				sources[f.Name] = sourceEnv
+			}
+			f.Value.Set(path)
+			return
+		}
+
+		value, source := f.DefValue, sourceDefault
+		if env, ok := os.LookupEnv(envName(f.Name)); ok {
+			value, source = env, sourceEnv
+		} else if v, ok := fileValues[f.Name]; ok {
+			value, source = v, sourceFile
+		}
+		if err := f.Value.Set(value); err != nil {
+			setErr = fmt.Errorf("invalid value %q for %s (from %s): %v", value, f.Name, source, err)
+			return
+		}
+		sources[f.Name] = source
+	})
+	if setErr != nil {
+		return nil, setErr
+	}
+	return sources, nil
+}
+
+// readConfigFile 读取 YAML/TOML/JSON 配置文件，返回以参数名为键的字符串值
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		err = json.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		values[name] = configValueString(name, value)
+	}
+	return values, nil
+}
+
+// configValueString 将配置文件中的值转换为参数可接受的字符串，列表按参数对应的分隔符拼接，
+// 映射按键排序后拼接为 key=value 列表
+func configValueString(name string, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, 0, len(v))
+		for _, key := range keys {
+			items = append(items, key+"="+fmt.Sprint(v[key]))
+		}
+		return strings.Join(items, ",")
+	case []interface{}:
+		sep, ok := listSeparators[name]
+		if !ok {
+			sep = ","
+		}
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, sep)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// envName 返回参数对应的环境变量名，例如 include-nics -> KOMARI_INCLUDE_NICS
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// maskSecret 隐藏敏感值，仅保留前后少量字符便于核对
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return "********"
+	}
+	return value[:2] + "********" + value[len(value)-2:]
+}
+
+// printConfig 输出当前生效的配置，并注明每一项的来源
+func printConfig(w io.Writer, fs *pflag.FlagSet, sources map[string]string) {
+	fs.VisitAll(func(f *pflag.Flag) {
+		value := f.Value.String()
+		if _, ok := secretFlags[f.Name]; ok {
+			value = maskSecret(value)
+		}
+		if f.Value.Type() == "string" {
+			value = fmt.Sprintf("%q", value)
+		}
+		source, ok := sources[f.Name]
+		if !ok {
+			source = sourceDefault
+		}
+		fmt.Fprintf(w, "%s: %s # %s\n", f.Name, value, source)
+	})
+}
+
+func init() {
+	ConfigCmd.AddCommand(configPrintCmd)
+	RootCmd.AddCommand(ConfigCmd)
+}
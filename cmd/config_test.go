@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newTestFlagSet() (*pflag.FlagSet, *string, *string, *float64, *string) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	var config, endpoint, token, nics string
+	var interval float64
+	fs.StringVar(&config, "config", "", "")
+	fs.StringVar(&endpoint, "endpoint", "", "")
+	fs.StringVar(&token, "token", "", "")
+	fs.Float64Var(&interval, "interval", 1.0, "")
+	fs.StringVar(&nics, "include-nics", "", "")
+	return fs, &endpoint, &token, &interval, &nics
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	formats := map[string]string{
+		"agent.yaml": "endpoint: https://file.example.com\ntoken: file-token\ninterval: 2\ninclude_nics: [eth0, eth1]\n",
+		"agent.toml": "endpoint = \"https://file.example.com\"\ntoken = \"file-token\"\ninterval = 2\ninclude-nics = [\"eth0\", \"eth1\"]\n",
+		"agent.json": `{"endpoint": "https://file.example.com", "token": "file-token", "interval": 2, "include-nics": ["eth0", "eth1"]}`,
+	}
+
+	for name, content := range formats {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("KOMARI_TOKEN", "env-token")
+			t.Setenv("KOMARI_INTERVAL", "3")
+
+			fs, endpoint, token, interval, nics := newTestFlagSet()
+			if err := fs.Parse([]string{"--interval", "4"}); err != nil {
+				t.Fatal(err)
+			}
+			cli := map[string]struct{}{"interval": {}}
+
+			sources, err := applyConfig(fs, cli, path)
+			if err != nil {
+				t.Fatalf("applyConfig failed: %v", err)
+			}
+			if *interval != 4 || sources["interval"] != sourceFlag {
+				t.Errorf("interval = %v (%s), want 4 from flag", *interval, sources["interval"])
+			}
+			if *token != "env-token" || sources["token"] != sourceEnv {
+				t.Errorf("token = %q (%s), want env-token from env", *token, sources["token"])
+			}
+			if *endpoint != "https://file.example.com" || sources["endpoint"] != sourceFile {
+				t.Errorf("endpoint = %q (%s), want file value", *endpoint, sources["endpoint"])
+			}
+			if *nics != "eth0,eth1" {
+				t.Errorf("include-nics = %q, want eth0,eth1", *nics)
+			}
+		})
+	}
+}
+
+func TestApplyConfigResetsRemovedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	os.WriteFile(path, []byte("interval: 5\n"), 0600)
+
+	fs, _, _, interval, _ := newTestFlagSet()
+	if _, err := applyConfig(fs, nil, path); err != nil {
+		t.Fatal(err)
+	}
+	if *interval != 5 {
+		t.Fatalf("interval = %v, want 5", *interval)
+	}
+
+	os.WriteFile(path, []byte("{}\n"), 0600)
+	if _, err := applyConfig(fs, nil, path); err != nil {
+		t.Fatal(err)
+	}
+	if *interval != 1 {
+		t.Errorf("interval = %v after removing key, want default 1", *interval)
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	unknown := filepath.Join(dir, "unknown.json")
+	os.WriteFile(unknown, []byte(`{"no-such-flag": 1}`), 0600)
+	invalid := filepath.Join(dir, "invalid.yaml")
+	os.WriteFile(invalid, []byte("interval: fast\n"), 0600)
+
+	for _, path := range []string{unknown, invalid, filepath.Join(dir, "missing.yaml")} {
+		fs, _, _, _, _ := newTestFlagSet()
+		if _, err := applyConfig(fs, nil, path); err == nil {
+			t.Errorf("applyConfig(%s) expected error", filepath.Base(path))
+		}
+	}
+}
+
+func TestPrintConfigMasksSecrets(t *testing.T) {
+	fs, _, _, _, _ := newTestFlagSet()
+	fs.Set("token", "abcdefghijklmnop")
+
+	var buf bytes.Buffer
+	printConfig(&buf, fs, map[string]string{"token": sourceFlag})
+	out := buf.String()
+	if strings.Contains(out, "abcdefghijklmnop") {
+		t.Errorf("token not masked:\n%s", out)
+	}
+	if !strings.Contains(out, `token: "ab********op" # flag`) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestConfigValueStringMap(t *testing.T) {
+	got := configValueString("collector-intervals", map[string]interface{}{"disk": "30s", "connections": "5s"})
+	if got != "connections=5s,disk=30s" {
+		t.Errorf("got %q, want connections=5s,disk=30s", got)
+	}
+}
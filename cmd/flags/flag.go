@@ -1,21 +1,79 @@
 package flags
 
-var (
-	AutoDiscoveryKey     string
-	DisableAutoUpdate   bool
-	DisableWebSsh       bool
-	MemoryModeAvailable bool
-	Token               string
-	Endpoint            string
-	Interval            float64
-	IgnoreUnsafeCert    bool
-	MaxRetries          int
-	ReconnectInterval   int
-	InfoReportInterval  int
-	IncludeNics         string
-	ExcludeNics         string
-	IncludeMountpoints  string
-	MonthRotate         int
-	CFAccessClientID    string
-	CFAccessClientSecret string
-)
+import "sync/atomic"
+
+// Config 运行参数
+type Config struct {
+	ConfigFile             string
+	AutoDiscoveryKey       string
+	DisableAutoUpdate      bool
+	DisableWebSsh          bool
+	MemoryModeAvailable    bool
+	Token                  string
+	Endpoint               string
+	Interval               float64
+	IgnoreUnsafeCert       bool
+	MaxRetries             int
+	ReconnectInterval      int
+	ReconnectPolicy        string
+	ReconnectMaxInterval   int
+	ReconnectJitter        float64
+	InfoReportInterval     int
+	IncludeNics            string
+	ExcludeNics            string
+	IncludeMountpoints     string
+	IncludeDiskDevices     string
+	ExcludeDiskDevices     string
+	MonthRotate            int
+	TrafficLedger          string
+	TrafficQuota           string
+	TrafficQuotaDirection  string
+	TrafficQuotaThresholds string
+	TrafficQuotaHook       string
+	ReportEncoding         string
+	WsCompression          bool
+	OfflineBuffer          string
+	OfflineBufferSize      int
+	OfflineBufferMaxAge    int
+	DisableCollectors      string
+	CollectorIntervals     string
+	SysfsRoot              string
+	TopProcesses           int
+	TopProcessesInterval   int
+	TopProcessesRedact     string
+	Watch                  string
+	SystemdUnits           string
+	ContainerSocket        string
+	IgnoreCgroupLimits     bool
+	CFAccessClientID       string
+	CFAccessClientSecret   string
+}
+
+// Parsed 由命令行参数、环境变量与配置文件解析得到的值。
+// 仅由 cmd 包在启动与重新加载配置时读写，其他包通过 Current 读取。
+var Parsed Config
+
+var current atomic.Pointer[Config]
+
+func init() {
+	current.Store(&Config{})
+}
+
+// Current 返回当前生效的配置。返回的快照不会被修改，重新加载配置时整体替换，
+// 因此可以在任意 goroutine 中读取。
+func Current() *Config {
+	return current.Load()
+}
+
+// Publish 将 Parsed 发布为当前配置
+func Publish() {
+	c := Parsed
+	current.Store(&c)
+}
+
+// Update 在当前配置的副本上修改并发布，用于测试
+func Update(f func(c *Config)) {
+	c := *current.Load()
+	f(&c)
+	current.Store(&c)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+	"github.com/komari-monitor/komari-agent/server"
+	"github.com/spf13/pflag"
+)
+
+// reconnectFlags 无法在线应用、变更后需要重新建立连接的参数
+var reconnectFlags = map[string]struct{}{
+	"endpoint":                {},
+	"token":                   {},
+	"ignore-unsafe-cert":      {},
+	"cf-access-client-id":     {},
+	"cf-access-client-secret": {},
+}
+
+// configPollInterval 检查配置文件是否变化的间隔
+const configPollInterval = 5 * time.Second
+
+// watchConfig 在收到 SIGHUP 或配置文件发生变化时重新加载配置
+func watchConfig(fs *pflag.FlagSet) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	lastMod := configModTime()
+	for {
+		select {
+		case <-sighup:
+			log.Println("Received SIGHUP, reloading config")
+			reloadConfig(fs)
+			lastMod = configModTime()
+		case <-ticker.C:
+			if flags.Parsed.ConfigFile == "" {
+				continue
+			}
+			if mod := configModTime(); !mod.Equal(lastMod) {
+				lastMod = mod
+				log.Println("Config file changed, reloading config")
+				reloadConfig(fs)
+			}
+		}
+	}
+}
+
+func configModTime() time.Time {
+	if flags.Parsed.ConfigFile == "" {
+		return time.Time{}
+	}
+	st, err := os.Stat(flags.Parsed.ConfigFile)
+	if err != nil {
+		return time.Time{}
+	}
+	return st.ModTime()
+}
+
+// reloadConfig 重新读取配置，成功后整体替换当前配置并通知上报循环，读取失败时保留原有配置。
+// 解析过程只修改 flags.Parsed，其他 goroutine 读取的快照不受影响。
+func reloadConfig(fs *pflag.FlagSet) {
+	before := snapshotFlags(fs)
+	if err := loadConfig(fs); err != nil {
+		log.Println("Failed to reload config, keeping previous settings:", err)
+		restoreFlags(fs, before)
+		return
+	}
+
+	reconnect := false
+	changed := 0
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Value.String() == before[f.Name] {
+			return
+		}
+		changed++
+		if _, ok := secretFlags[f.Name]; ok {
+			log.Printf("Config %s changed", f.Name)
+		} else {
+			log.Printf("Config %s changed: %s -> %s", f.Name, before[f.Name], f.Value.String())
+		}
+		if _, ok := reconnectFlags[f.Name]; ok {
+			reconnect = true
+		}
+	})
+	if changed == 0 {
+		log.Println("Config reloaded, nothing changed")
+		return
+	}
+
+	flags.Publish()
+	server.ApplyTLSConfig()
+	if diskList, err := monitoring.DiskList(); err == nil {
+		log.Println("Monitoring Mountpoints:", diskList)
+	}
+	if diskDevices, err := monitoring.DiskDeviceList(); err == nil {
+		log.Println("Monitoring Disk Devices:", diskDevices)
+	}
+	if interfaceList, err := monitoring.InterfaceList(); err == nil {
+		log.Println("Monitoring Interfaces:", interfaceList)
+	}
+	server.NotifyConfigChanged(reconnect)
+}
+
+func snapshotFlags(fs *pflag.FlagSet) map[string]string {
+	values := map[string]string{}
+	fs.VisitAll(func(f *pflag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+	return values
+}
+
+func restoreFlags(fs *pflag.FlagSet, values map[string]string) {
+	fs.VisitAll(func(f *pflag.Flag) {
+		if v, ok := values[f.Name]; ok && f.Value.String() != v {
+			f.Value.Set(v)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/spf13/pflag"
+)
+
+// newReloadFlagSet 绑定到 flags.Parsed 的参数集，模拟以 --config 启动后的状态
+func newReloadFlagSet(t *testing.T, path, content string) *pflag.FlagSet {
+	t.Helper()
+	oldParsed, oldCurrent, oldCLI := flags.Parsed, *flags.Current(), cliFlags
+	t.Cleanup(func() {
+		flags.Parsed, cliFlags = oldParsed, oldCLI
+		flags.Update(func(c *flags.Config) { *c = oldCurrent })
+	})
+
+	flags.Parsed = flags.Config{}
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringVar(&flags.Parsed.ConfigFile, "config", "", "")
+	fs.StringVar(&flags.Parsed.Endpoint, "endpoint", "", "")
+	fs.Float64Var(&flags.Parsed.Interval, "interval", 1.0, "")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Parse([]string{"--config", path}); err != nil {
+		t.Fatal(err)
+	}
+	cliFlags = nil
+	if err := loadConfig(fs); err != nil {
+		t.Fatal(err)
+	}
+	flags.Publish()
+	return fs
+}
+
+func TestReloadConfigAppliesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	fs := newReloadFlagSet(t, path, "endpoint: https://panel.example.com\ninterval: 2\n")
+	before := flags.Current()
+	if before.Interval != 2 || before.Endpoint != "https://panel.example.com" {
+		t.Fatalf("initial config = %+v", before)
+	}
+
+	if err := os.WriteFile(path, []byte("endpoint: https://panel.example.com\ninterval: 5\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	reloadConfig(fs)
+	if got := flags.Current(); got.Interval != 5 || got.Endpoint != "https://panel.example.com" {
+		t.Errorf("reloaded config = %+v, want interval 5", got)
+	}
+	// 已取得的快照不受重新加载影响
+	if before.Interval != 2 {
+		t.Errorf("previous snapshot changed to %v", before.Interval)
+	}
+}
+
+func TestReloadConfigKeepsPreviousOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	fs := newReloadFlagSet(t, path, "endpoint: https://panel.example.com\ninterval: 2\n")
+
+	// endpoint 已写入后 interval 才解析失败，需要整体回退
+	if err := os.WriteFile(path, []byte("endpoint: https://other.example.com\ninterval: fast\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	reloadConfig(fs)
+	if got := flags.Current(); got.Interval != 2 || got.Endpoint != "https://panel.example.com" {
+		t.Errorf("config after failed reload = %+v, want the previous values", got)
+	}
+	if flags.Parsed.Endpoint != "https://panel.example.com" {
+		t.Errorf("parsed endpoint = %q, want the previous value restored", flags.Parsed.Endpoint)
+	}
+
+	if err := os.WriteFile(path, []byte("interval: 5\nno_such_option: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	reloadConfig(fs)
+	if got := flags.Current(); got.Interval != 2 {
+		t.Errorf("config with an unknown key = %+v, want interval 2", got)
+	}
+}
+
+func TestReloadConfigConcurrentReaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	fs := newReloadFlagSet(t, path, "interval: 2\n")
+
+	// 与上报循环一样在其他 goroutine 中持续读取配置，-race 下不应报告数据竞争
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = flags.Current().Interval
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		content := "interval: 3\n"
+		if i%2 == 1 {
+			content = "interval: 4\n"
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		reloadConfig(fs)
+	}
+	close(stop)
+	<-done
+	if got := flags.Current().Interval; got != 4 {
+		t.Errorf("interval = %v, want 4", got)
+	}
+}
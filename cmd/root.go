@@ -1,12 +1,11 @@
 package cmd
 
 import (
-	"crypto/tls"
 	"log"
-	"net/http"
 	"os"
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
+	report "github.com/komari-monitor/komari-agent/monitoring"
 	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
 	"github.com/komari-monitor/komari-agent/server"
 	"github.com/komari-monitor/komari-agent/update"
@@ -17,22 +16,44 @@ var RootCmd = &cobra.Command{
 	Use:   "komari-agent",
 	Short: "komari agent",
 	Long:  `komari agent`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(cmd.Root().PersistentFlags()); err != nil {
+			return err
+		}
+		flags.Publish()
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Println("Komari Agent", update.CurrentVersion)
 		log.Println("Github Repo:", update.Repo)
+		if flags.Parsed.Endpoint == "" {
+			log.Println(`required flag "endpoint" not set, use --endpoint, KOMARI_ENDPOINT or the config file`)
+			os.Exit(1)
+		}
+		if flags.Parsed.ConfigFile != "" {
+			log.Println("Using config file:", flags.Parsed.ConfigFile)
+		}
 		// Auto discovery
-		if flags.AutoDiscoveryKey != "" {
+		if flags.Parsed.AutoDiscoveryKey != "" {
 			err := handleAutoDiscovery()
 			if err != nil {
 				log.Printf("Auto-discovery failed: %v", err)
 				os.Exit(1)
 			}
+			// 自动发现得到的 token 不随配置重新加载而改变
+			pinFlag("token")
+			flags.Publish()
 		}
 		diskList, err := monitoring.DiskList()
 		if err != nil {
 			log.Println("Failed to get disk list:", err)
 		}
 		log.Println("Monitoring Mountpoints:", diskList)
+		diskDevices, err := monitoring.DiskDeviceList()
+		if err != nil {
+			log.Println("Failed to get disk device list:", err)
+		}
+		log.Println("Monitoring Disk Devices:", diskDevices)
 		interfaceList, err := monitoring.InterfaceList()
 		if err != nil {
 			log.Println("Failed to get interface list:", err)
@@ -40,19 +61,22 @@ var RootCmd = &cobra.Command{
 		log.Println("Monitoring Interfaces:", interfaceList)
 
 		// 忽略不安全的证书
-		if flags.IgnoreUnsafeCert {
-			http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-		}
+		server.ApplyTLSConfig()
 		// 自动更新
-		if !flags.DisableAutoUpdate {
+		if !flags.Parsed.DisableAutoUpdate {
 			err := update.CheckAndUpdate()
 			if err != nil {
 				log.Println("[ERROR]", err)
 			}
 			go update.DoUpdateWorks()
 		}
+		go watchConfig(cmd.Root().PersistentFlags())
 		go server.DoUploadBasicInfoWorks()
+		// 流量配额阈值与钩子不依赖上报连接
+		go report.WatchTrafficQuota()
 		for {
+			// 达到 --max-retries 后重新上传基础信息并重连，同样遵循退避策略
+			server.WaitReconnectBackoff()
 			server.UpdateBasicInfo()
 			server.EstablishWebSocketConnection()
 		}
@@ -75,24 +99,49 @@ func Execute() {
 }
 
 func init() {
-	RootCmd.PersistentFlags().StringVarP(&flags.Token, "token", "t", "", "API token")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.ConfigFile, "config", "", "Path to a YAML, TOML or JSON config file")
+	RootCmd.PersistentFlags().StringVarP(&flags.Parsed.Token, "token", "t", "", "API token")
 	//RootCmd.MarkPersistentFlagRequired("token")
-	RootCmd.PersistentFlags().StringVarP(&flags.Endpoint, "endpoint", "e", "", "API endpoint")
-	RootCmd.MarkPersistentFlagRequired("endpoint")
-	RootCmd.PersistentFlags().StringVar(&flags.AutoDiscoveryKey, "auto-discovery", "", "Auto discovery key for the agent")
-	RootCmd.PersistentFlags().BoolVar(&flags.DisableAutoUpdate, "disable-auto-update", false, "Disable automatic updates")
-	RootCmd.PersistentFlags().BoolVar(&flags.DisableWebSsh, "disable-web-ssh", false, "Disable remote control(web ssh and rce)")
-	RootCmd.PersistentFlags().BoolVar(&flags.MemoryModeAvailable, "memory-mode-available", false, "Report memory as available instead of used.")
-	RootCmd.PersistentFlags().Float64VarP(&flags.Interval, "interval", "i", 1.0, "Interval in seconds")
-	RootCmd.PersistentFlags().BoolVarP(&flags.IgnoreUnsafeCert, "ignore-unsafe-cert", "u", false, "Ignore unsafe certificate errors")
-	RootCmd.PersistentFlags().IntVarP(&flags.MaxRetries, "max-retries", "r", 3, "Maximum number of retries")
-	RootCmd.PersistentFlags().IntVarP(&flags.ReconnectInterval, "reconnect-interval", "c", 5, "Reconnect interval in seconds")
-	RootCmd.PersistentFlags().IntVar(&flags.InfoReportInterval, "info-report-interval", 5, "Interval in minutes for reporting basic info")
-	RootCmd.PersistentFlags().StringVar(&flags.IncludeNics, "include-nics", "", "Comma-separated list of network interfaces to include")
-	RootCmd.PersistentFlags().StringVar(&flags.ExcludeNics, "exclude-nics", "", "Comma-separated list of network interfaces to exclude")
-	RootCmd.PersistentFlags().StringVar(&flags.IncludeMountpoints, "include-mountpoint", "", "Semicolon-separated list of mount points to include for disk statistics")
-	RootCmd.PersistentFlags().IntVar(&flags.MonthRotate, "month-rotate", 0, "Month reset for network statistics (0 to disable)")
-	RootCmd.PersistentFlags().StringVar(&flags.CFAccessClientID, "cf-access-client-id", "", "Cloudflare Access Client ID")
-	RootCmd.PersistentFlags().StringVar(&flags.CFAccessClientSecret, "cf-access-client-secret", "", "Cloudflare Access Client Secret")
+	RootCmd.PersistentFlags().StringVarP(&flags.Parsed.Endpoint, "endpoint", "e", "", "API endpoint")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.AutoDiscoveryKey, "auto-discovery", "", "Auto discovery key for the agent")
+	RootCmd.PersistentFlags().BoolVar(&flags.Parsed.DisableAutoUpdate, "disable-auto-update", false, "Disable automatic updates")
+	RootCmd.PersistentFlags().BoolVar(&flags.Parsed.DisableWebSsh, "disable-web-ssh", false, "Disable remote control(web ssh and rce)")
+	RootCmd.PersistentFlags().BoolVar(&flags.Parsed.MemoryModeAvailable, "memory-mode-available", false, "Report memory as available instead of used.")
+	RootCmd.PersistentFlags().Float64VarP(&flags.Parsed.Interval, "interval", "i", 1.0, "Interval in seconds")
+	RootCmd.PersistentFlags().BoolVarP(&flags.Parsed.IgnoreUnsafeCert, "ignore-unsafe-cert", "u", false, "Ignore unsafe certificate errors")
+	RootCmd.PersistentFlags().IntVarP(&flags.Parsed.MaxRetries, "max-retries", "r", 3, "Maximum number of retries before re-uploading basic info (-1 for unlimited)")
+	RootCmd.PersistentFlags().IntVarP(&flags.Parsed.ReconnectInterval, "reconnect-interval", "c", 5, "Reconnect interval in seconds")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.ReconnectPolicy, "reconnect-policy", "exponential", "Reconnect backoff policy: exponential or fixed")
+	RootCmd.PersistentFlags().IntVar(&flags.Parsed.ReconnectMaxInterval, "reconnect-max-interval", 300, "Maximum reconnect backoff in seconds")
+	RootCmd.PersistentFlags().Float64Var(&flags.Parsed.ReconnectJitter, "reconnect-jitter", 0.2, "Random jitter applied to the reconnect backoff (0-1)")
+	RootCmd.PersistentFlags().IntVar(&flags.Parsed.InfoReportInterval, "info-report-interval", 5, "Interval in minutes for reporting basic info")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.IncludeNics, "include-nics", "", "Comma-separated list of network interfaces to include")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.ExcludeNics, "exclude-nics", "", "Comma-separated list of network interfaces to exclude")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.IncludeMountpoints, "include-mountpoint", "", "Semicolon-separated list of mount points to include for disk statistics")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.IncludeDiskDevices, "include-disk-devices", "", "Comma-separated list of block devices to include for disk I/O statistics")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.ExcludeDiskDevices, "exclude-disk-devices", "", "Comma-separated list of block devices to exclude from disk I/O statistics")
+	RootCmd.PersistentFlags().IntVar(&flags.Parsed.MonthRotate, "month-rotate", 0, "Month reset for network statistics (0 to disable)")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.TrafficLedger, "traffic-ledger", "", "Path of the monthly traffic ledger used with --month-rotate (default: traffic-ledger.json next to the executable)")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.TrafficQuota, "traffic-quota", "", "Traffic quota per --month-rotate period, e.g. 1TB or 500GiB (empty to disable)")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.TrafficQuotaDirection, "traffic-quota-direction", "sum", "Traffic counted against the quota: up, down or sum")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.TrafficQuotaThresholds, "traffic-quota-thresholds", "80,95,100", "Comma-separated quota percentages that send a quota_warning")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.TrafficQuotaHook, "traffic-quota-hook", "", "Command run when a quota threshold is reached, details are passed in KOMARI_QUOTA_* environment variables")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.ReportEncoding, "report-encoding", "auto", "Report encoding offered to the server: auto, json, msgpack or cbor")
+	RootCmd.PersistentFlags().BoolVar(&flags.Parsed.WsCompression, "ws-compression", false, "Request permessage-deflate compression for the report connection")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.OfflineBuffer, "offline-buffer", "", "Path of the on-disk buffer for reports taken while disconnected (empty to disable)")
+	RootCmd.PersistentFlags().IntVar(&flags.Parsed.OfflineBufferSize, "offline-buffer-size", 16, "Maximum size of the offline buffer in MB")
+	RootCmd.PersistentFlags().IntVar(&flags.Parsed.OfflineBufferMaxAge, "offline-buffer-max-age", 24, "Maximum age of buffered reports in hours")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.DisableCollectors, "disable-collectors", "", "Comma-separated list of report collectors to disable")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.CollectorIntervals, "collector-intervals", "", "Per-collector collection intervals, e.g. disk=30s,connections=5s")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.SysfsRoot, "sysfs-root", "/sys", "Root of the sysfs tree read for sensors, disk partitions, PSI, zram/zswap, cgroup limits and watched systemd units")
+	RootCmd.PersistentFlags().IntVar(&flags.Parsed.TopProcesses, "top-processes", 0, "Number of top processes by CPU and memory to report (0 to disable)")
+	RootCmd.PersistentFlags().IntVar(&flags.Parsed.TopProcessesInterval, "top-processes-interval", 30, "Top processes collection interval in seconds")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.TopProcessesRedact, "top-processes-redact", "", "Semicolon-separated regular expressions hidden from reported command lines, in addition to password and token arguments")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.Watch, "watch", "", "Semicolon-separated processes to watch: name:nginx, regex:<expression>, pidfile:<path> or unit:<systemd unit>")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.SystemdUnits, "systemd-units", "", "Comma-separated systemd units whose state is reported, failed units are always reported")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.ContainerSocket, "container-socket", "", "Docker or Podman API socket for container metrics, auto to detect (empty to disable)")
+	RootCmd.PersistentFlags().BoolVar(&flags.Parsed.IgnoreCgroupLimits, "ignore-cgroup-limits", false, "Report host memory and CPU totals instead of the container's cgroup limits")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.CFAccessClientID, "cf-access-client-id", "", "Cloudflare Access Client ID")
+	RootCmd.PersistentFlags().StringVar(&flags.Parsed.CFAccessClientSecret, "cf-access-client-secret", "", "Cloudflare Access Client Secret")
 	RootCmd.PersistentFlags().ParseErrorsWhitelist.UnknownFlags = true
 }
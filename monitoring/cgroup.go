@@ -0,0 +1,34 @@
+package monitoring
+
+import (
+	"context"
+	"runtime"
+
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+func (m *CgroupReport) Apply(r *Report) { r.Cgroup = m }
+
+type cgroupCollector struct{}
+
+func (cgroupCollector) Name() string { return "cgroup" }
+
+// Enabled cgroup 仅 Linux 提供
+func (cgroupCollector) Enabled() bool { return runtime.GOOS == "linux" }
+
+// Collect 不在容器中运行时省略
+func (cgroupCollector) Collect(ctx context.Context) (Metrics, error) {
+	limits, ok := monitoring.ContainerLimits()
+	if !ok {
+		return nil, nil
+	}
+	return &CgroupReport{
+		Version:       limits.Version,
+		MemLimit:      limits.MemLimit,
+		MemUsed:       limits.MemUsed,
+		CPUQuota:      limits.CPUQuota,
+		NrPeriods:     limits.NrPeriods,
+		NrThrottled:   limits.NrThrottled,
+		ThrottledTime: limits.ThrottledTime,
+	}, nil
+}
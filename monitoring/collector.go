@@ -0,0 +1,251 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// Collector 实时报告中的一个采集器。
+//
+// 新的采集器实现该接口并在 init 中调用 Register 即可加入报告，无需修改本包：
+//
+//	func init() { monitoring.Register(myCollector{}) }
+type Collector interface {
+	// Name 采集器名称，在 --disable-collectors、--collector-intervals 与日志中使用
+	Name() string
+	// Enabled 是否启用，可根据自身配置或运行环境决定，每次采集前检查
+	Enabled() bool
+	// Collect 采集一次数据。出错时也可以返回部分结果。
+	Collect(ctx context.Context) (Metrics, error)
+}
+
+// IntervalCollector 可选接口，默认以低于上报频率运行的采集器实现。
+// --collector-intervals 中的设置优先。
+type IntervalCollector interface {
+	Collector
+	// Interval 默认采集间隔，两次采集之间沿用上一次的结果
+	Interval() time.Duration
+}
+
+// TimeoutCollector 可选接口，单次采集可能超过上报间隔的采集器实现。
+// 报告最多等待默认超时，之后沿用上一次的结果，采集在后台继续运行直到 Timeout。
+type TimeoutCollector interface {
+	Collector
+	// Timeout 单次采集的超时
+	Timeout() time.Duration
+}
+
+// Metrics 采集结果，Apply 将其写入报告
+type Metrics interface {
+	Apply(r *Report)
+}
+
+// CustomMetrics 第三方采集器的结果，写入报告的 custom 字段
+type CustomMetrics struct {
+	Name  string
+	Value interface{}
+}
+
+func (m CustomMetrics) Apply(r *Report) {
+	if r.Custom == nil {
+		r.Custom = map[string]interface{}{}
+	}
+	r.Custom[m.Name] = m.Value
+}
+
+// scheduledCollector 已注册的采集器及其调度状态
+type scheduledCollector struct {
+	Collector
+
+	mu      sync.Mutex
+	running bool
+	last    Metrics
+	lastAt  time.Time
+	lastErr error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []*scheduledCollector
+)
+
+// Register 注册采集器，名称重复时 panic
+func Register(c Collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, sc := range registry {
+		if sc.Name() == c.Name() {
+			panic("monitoring: Register called twice for collector " + c.Name())
+		}
+	}
+	registry = append(registry, &scheduledCollector{Collector: c})
+}
+
+// CollectorNames 返回已注册采集器的名称，按注册顺序排列
+func CollectorNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for _, sc := range registry {
+		names = append(names, sc.Name())
+	}
+	return names
+}
+
+func registered() []*scheduledCollector {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]*scheduledCollector(nil), registry...)
+}
+
+// collectorConfig 由 --disable-collectors 与 --collector-intervals 解析得到的配置
+type collectorConfig struct {
+	disabled  map[string]struct{}
+	intervals map[string]time.Duration
+}
+
+var (
+	configMu     sync.Mutex
+	configSource string
+	configCache  collectorConfig
+)
+
+// currentCollectorConfig 解析采集器配置，参数未变化时复用上次结果
+func currentCollectorConfig() collectorConfig {
+	configMu.Lock()
+	defer configMu.Unlock()
+	source := flags.Current().DisableCollectors + "\x00" + flags.Current().CollectorIntervals
+	if source == configSource && configCache.disabled != nil {
+		return configCache
+	}
+	cfg, err := parseCollectorConfig(flags.Current().DisableCollectors, flags.Current().CollectorIntervals)
+	if err != nil {
+		log.Println("Invalid collector config:", err)
+	}
+	known := map[string]struct{}{}
+	for _, name := range CollectorNames() {
+		known[name] = struct{}{}
+	}
+	for name := range cfg.disabled {
+		if _, ok := known[name]; !ok {
+			log.Printf("Unknown collector %q in --disable-collectors, available: %s", name, strings.Join(CollectorNames(), ","))
+		}
+	}
+	for name := range cfg.intervals {
+		if _, ok := known[name]; !ok {
+			log.Printf("Unknown collector %q in --collector-intervals, available: %s", name, strings.Join(CollectorNames(), ","))
+		}
+	}
+	configSource, configCache = source, cfg
+	return cfg
+}
+
+// parseCollectorConfig 解析 "a,b" 形式的禁用列表与 "name=30s,name2=5m" 形式的采集间隔。
+// 无法解析的间隔会被跳过并返回错误。
+func parseCollectorConfig(disabled, intervals string) (collectorConfig, error) {
+	cfg := collectorConfig{
+		disabled:  map[string]struct{}{},
+		intervals: map[string]time.Duration{},
+	}
+	for _, name := range strings.Split(disabled, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.disabled[name] = struct{}{}
+		}
+	}
+	var errs []string
+	for _, item := range strings.Split(intervals, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%q is not name=duration", item))
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			errs = append(errs, fmt.Sprintf("invalid interval for %s: %q", name, value))
+			continue
+		}
+		cfg.intervals[strings.TrimSpace(name)] = d
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return cfg, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return cfg, nil
+}
+
+// interval 返回采集间隔，未在 --collector-intervals 中设置时使用采集器自身的默认值
+func (sc *scheduledCollector) interval(cfg collectorConfig) time.Duration {
+	if d, ok := cfg.intervals[sc.Name()]; ok {
+		return d
+	}
+	if ic, ok := sc.Collector.(IntervalCollector); ok {
+		return ic.Interval()
+	}
+	return 0
+}
+
+// maxIntervalTimeout 由采集间隔推导的超时上限，避免很长的间隔让卡住的采集长期占用
+const maxIntervalTimeout = time.Minute
+
+// timeout 返回单次采集的超时，不短于 def。
+// 优先使用 TimeoutCollector，否则为采集间隔（最多 maxIntervalTimeout），
+// 以低于上报频率运行的采集器因此有整个间隔完成采集。
+func (sc *scheduledCollector) timeout(cfg collectorConfig, def time.Duration) time.Duration {
+	d := min(sc.interval(cfg), maxIntervalTimeout)
+	if tc, ok := sc.Collector.(TimeoutCollector); ok && tc.Timeout() > 0 {
+		d = tc.Timeout()
+	}
+	return max(d, def)
+}
+
+// start 标记开始采集，上一次采集仍未结束时返回 false
+func (sc *scheduledCollector) start() bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.running {
+		return false
+	}
+	sc.running = true
+	return true
+}
+
+// finish 结束采集并缓存结果，供之后超时或未到采集间隔时沿用。
+// 采集失败时保留上一次的结果；成功但没有数据（例如 GPU 或容器已经消失）时清除，不再沿用旧数据。
+func (sc *scheduledCollector) finish(m Metrics, err error, at time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.running = false
+	sc.lastErr = err
+	if m != nil || err == nil {
+		sc.last = m
+		sc.lastAt = at
+	}
+}
+
+// cached 返回上一次的结果，可能为 nil；fresh 表示该结果仍在 interval 之内
+func (sc *scheduledCollector) cached(interval time.Duration, now time.Time) (m Metrics, fresh bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	fresh = !sc.lastAt.IsZero() && interval > 0 && now.Sub(sc.lastAt) < interval
+	return sc.last, fresh
+}
+
+// takeErr 取出并清除上一次采集的错误，用于上报在后台完成的采集
+func (sc *scheduledCollector) takeErr() error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	err := sc.lastErr
+	sc.lastErr = nil
+	return err
+}
@@ -0,0 +1,131 @@
+package monitoring
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+// containersInterval 容器状态的默认查询间隔
+const containersInterval = 10 * time.Second
+
+// ContainerEvent 容器停止或变为 unhealthy 时推送的事件
+type ContainerEvent struct {
+	// Type 固定为 container_state
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Image     string    `json:"image"`
+	// Status stopped 或 unhealthy
+	Status string `json:"status"`
+	// State 容器当前状态，运行中的容器被删除时为 removed
+	State  string `json:"state"`
+	Health string `json:"health"`
+}
+
+// containerState 上一次看到的各容器状态
+type containerState struct {
+	mu   sync.Mutex
+	last map[string]monitoring.ContainerInfo
+}
+
+var containerStates containerState
+
+// update 记录本次的容器列表，返回运行中的容器停止或健康检查变为 unhealthy 的事件。首次调用只记录状态。
+func (s *containerState) update(containers []monitoring.ContainerInfo, now time.Time) []ContainerEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := make(map[string]monitoring.ContainerInfo, len(containers))
+	var events []ContainerEvent
+	event := func(c monitoring.ContainerInfo, status string) {
+		events = append(events, ContainerEvent{
+			Type:      "container_state",
+			Timestamp: now,
+			ID:        c.ID,
+			Name:      c.Name,
+			Image:     c.Image,
+			Status:    status,
+			State:     c.State,
+			Health:    c.Health,
+		})
+	}
+	for _, c := range containers {
+		current[c.ID] = c
+		prev, ok := s.last[c.ID]
+		if !ok {
+			continue
+		}
+		if c.Health == "" && prev.Health != "" {
+			// 本次未能查询到详情，健康检查不会在容器运行期间消失，沿用上一次的状态
+			c.Health = prev.Health
+			current[c.ID] = c
+		}
+		if prev.State == "running" && c.State != "running" && c.State != "paused" {
+			event(c, "stopped")
+		}
+		if c.Health == "unhealthy" && prev.Health != "unhealthy" {
+			event(c, "unhealthy")
+		}
+	}
+	for id, prev := range s.last {
+		if _, ok := current[id]; !ok && prev.State == "running" {
+			prev.State = "removed"
+			event(prev, "stopped")
+		}
+	}
+	s.last = current
+	return events
+}
+
+// containerMetrics 各容器的状态
+type containerMetrics []ContainerReport
+
+func (m containerMetrics) Apply(r *Report) { r.Containers = m }
+
+type containersCollector struct{}
+
+func (containersCollector) Name() string { return "containers" }
+
+// Enabled 设置了 --container-socket 时启用，Windows 上的 Docker 使用命名管道，暂不支持
+func (containersCollector) Enabled() bool {
+	return flags.Current().ContainerSocket != "" && runtime.GOOS != "windows"
+}
+func (containersCollector) Interval() time.Duration { return containersInterval }
+func (containersCollector) Collect(ctx context.Context) (Metrics, error) {
+	socket, err := monitoring.ContainerSocket(flags.Current().ContainerSocket)
+	if err != nil {
+		return nil, err
+	}
+	// 超时时仍返回已取得的部分结果
+	containers, err := monitoring.Containers(ctx, socket)
+	if containers == nil && err != nil {
+		return nil, err
+	}
+	for _, ev := range containerStates.update(containers, time.Now()) {
+		log.Printf("Container %s (%s) is %s, state %s", ev.Name, ev.ID, ev.Status, ev.State)
+		emitEvent(ev)
+	}
+	metrics := make(containerMetrics, 0, len(containers))
+	for _, c := range containers {
+		metrics = append(metrics, ContainerReport{
+			ID:           c.ID,
+			Name:         c.Name,
+			Image:        c.Image,
+			State:        c.State,
+			Health:       c.Health,
+			RestartCount: c.RestartCount,
+			CPU:          c.CPU,
+			MemUsed:      c.MemUsed,
+			MemLimit:     c.MemLimit,
+			NetRx:        c.NetRx,
+			NetTx:        c.NetTx,
+		})
+	}
+	return metrics, err
+}
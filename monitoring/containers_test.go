@@ -0,0 +1,47 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+func TestContainerStateEvents(t *testing.T) {
+	state := &containerState{}
+	now := time.Now()
+	web := monitoring.ContainerInfo{ID: "web", Name: "web", State: "running", Health: "healthy"}
+	db := monitoring.ContainerInfo{ID: "db", Name: "db", State: "running"}
+	job := monitoring.ContainerInfo{ID: "job", Name: "job", State: "exited"}
+
+	if events := state.update([]monitoring.ContainerInfo{web, db, job}, now); len(events) != 0 {
+		t.Fatalf("first update = %+v", events)
+	}
+
+	web.Health = "unhealthy"
+	db.State = "exited"
+	events := state.update([]monitoring.ContainerInfo{web, db, job}, now)
+	if len(events) != 2 || events[0].Name != "web" || events[0].Status != "unhealthy" ||
+		events[1].Name != "db" || events[1].Status != "stopped" || events[1].State != "exited" || events[1].Type != "container_state" {
+		t.Fatalf("events = %+v", events)
+	}
+	// 状态不变时不重复推送
+	if events := state.update([]monitoring.ContainerInfo{web, db, job}, now); len(events) != 0 {
+		t.Errorf("repeated events = %+v", events)
+	}
+	// 超时未查询到健康状态时不会在恢复后再次推送 unhealthy
+	partial := web
+	partial.Health = ""
+	if events := state.update([]monitoring.ContainerInfo{partial, db, job}, now); len(events) != 0 {
+		t.Errorf("events without health = %+v", events)
+	}
+	if events := state.update([]monitoring.ContainerInfo{web, db, job}, now); len(events) != 0 {
+		t.Errorf("unhealthy reported again after a partial update: %+v", events)
+	}
+
+	// 运行中的容器被删除
+	events = state.update([]monitoring.ContainerInfo{db, job}, now)
+	if len(events) != 1 || events[0].Name != "web" || events[0].State != "removed" {
+		t.Errorf("removal events = %+v", events)
+	}
+}
@@ -0,0 +1,36 @@
+package monitoring
+
+import (
+	"context"
+
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+// diskIOMetrics 各块设备的读写情况
+type diskIOMetrics []DiskIOReport
+
+func (m diskIOMetrics) Apply(r *Report) { r.DiskIO = m }
+
+type diskIOCollector struct{}
+
+func (diskIOCollector) Name() string  { return "disk_io" }
+func (diskIOCollector) Enabled() bool { return true }
+func (diskIOCollector) Collect(ctx context.Context) (Metrics, error) {
+	devices, err := monitoring.DiskIO()
+	if err != nil {
+		return nil, err
+	}
+	metrics := make(diskIOMetrics, 0, len(devices))
+	for _, d := range devices {
+		metrics = append(metrics, DiskIOReport{
+			Name:       d.Name,
+			ReadSpeed:  d.ReadSpeed,
+			WriteSpeed: d.WriteSpeed,
+			ReadIOPS:   d.ReadIOPS,
+			WriteIOPS:  d.WriteIOPS,
+			Await:      d.Await,
+			Util:       d.Util,
+		})
+	}
+	return metrics, nil
+}
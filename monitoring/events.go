@@ -0,0 +1,27 @@
+package monitoring
+
+import "log"
+
+// events 采集器产生、需要即时推送给服务端的事件，由上报循环发送
+var events = make(chan interface{}, 64)
+
+// Events 返回事件通道。事件为可直接编码发送的结构体，包含 type 字段。
+func Events() <-chan interface{} {
+	return events
+}
+
+// emitEvent 非阻塞发送事件，通道已满时丢弃最早的事件
+func emitEvent(ev interface{}) {
+	for {
+		select {
+		case events <- ev:
+			return
+		default:
+		}
+		select {
+		case dropped := <-events:
+			log.Printf("Event queue is full, dropping %T", dropped)
+		default:
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package monitoring
+
+import (
+	"context"
+	"time"
+
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+// gpuMetrics 各 GPU 的使用情况
+type gpuMetrics []GPUReport
+
+func (m gpuMetrics) Apply(r *Report) { r.GPU = m }
+
+type gpuCollector struct{}
+
+func (gpuCollector) Name() string { return "gpu" }
+
+// Enabled 需要 PATH 中有 nvidia-smi 或 rocm-smi
+func (gpuCollector) Enabled() bool {
+	nvidia, rocm := monitoring.GpuTools()
+	return nvidia != "" || rocm != ""
+}
+
+// Interval 每次采集需要启动外部命令，默认 5 秒一次
+func (gpuCollector) Interval() time.Duration { return 5 * time.Second }
+
+func (gpuCollector) Collect(ctx context.Context) (Metrics, error) {
+	gpus, err := monitoring.Gpus(ctx)
+	if len(gpus) == 0 {
+		return nil, err
+	}
+	report := make(gpuMetrics, 0, len(gpus))
+	for _, gpu := range gpus {
+		r := GPUReport{
+			Index:       gpu.Index,
+			Name:        gpu.Name,
+			Vendor:      gpu.Vendor,
+			Utilization: gpu.Utilization,
+			MemUsed:     gpu.MemUsed,
+			MemTotal:    gpu.MemTotal,
+			Temperature: gpu.Temperature,
+			PowerDraw:   gpu.PowerDraw,
+		}
+		for _, p := range gpu.Processes {
+			r.Processes = append(r.Processes, GPUProcessReport{PID: p.PID, Name: p.Name, MemUsed: p.MemUsed})
+		}
+		report = append(report, r)
+	}
+	return report, err
+}
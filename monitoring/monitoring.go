@@ -1,82 +1,257 @@
 package monitoring
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 
 	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
 )
 
-func GenerateReport() []byte {
-	message := ""
-	data := map[string]interface{}{}
+func init() {
+	Register(cpuCollector{})
+	Register(memoryCollector{})
+	Register(loadCollector{})
+	Register(psiCollector{})
+	Register(cgroupCollector{})
+	Register(diskCollector{})
+	Register(diskIOCollector{})
+	Register(networkCollector{})
+	Register(quotaCollector{})
+	Register(connectionsCollector{})
+	Register(sensorsCollector{})
+	Register(gpuCollector{})
+	Register(uptimeCollector{})
+	Register(processCountCollector{})
+	Register(topProcessesCollector{})
+	Register(watchCollector{})
+	Register(systemdCollector{})
+	Register(containersCollector{})
+}
+
+func (m CPUReport) Apply(r *Report)         { r.CPU = m }
+func (m LoadReport) Apply(r *Report)        { r.Load = m }
+func (m DiskReport) Apply(r *Report)        { r.Disk = m }
+func (m NetworkReport) Apply(r *Report)     { r.Network = m }
+func (m ConnectionsReport) Apply(r *Report) { r.Connections = m }
+
+// memoryMetrics 内存与交换空间
+type memoryMetrics struct {
+	RAM    MemoryReport
+	Swap   MemoryReport
+	Detail *MemoryDetailReport
+}
+
+func (m memoryMetrics) Apply(r *Report) {
+	r.RAM = m.RAM
+	r.Swap = m.Swap
+	r.MemoryDetail = m.Detail
+}
+
+// uptimeMetrics 系统运行时间，单位秒
+type uptimeMetrics uint64
+
+func (m uptimeMetrics) Apply(r *Report) { r.Uptime = uint64(m) }
+
+// processCountMetrics 进程数
+type processCountMetrics int
+
+func (m processCountMetrics) Apply(r *Report) { r.Process = int(m) }
+
+type cpuCollector struct{}
 
-	cpu := monitoring.Cpu()
-	cpuUsage := cpu.CPUUsage
-	if cpuUsage <= 0.001 {
-		cpuUsage = 0.001
+func (cpuCollector) Name() string  { return "cpu" }
+func (cpuCollector) Enabled() bool { return true }
+func (cpuCollector) Collect(ctx context.Context) (Metrics, error) {
+	detail, err := monitoring.CpuDetailed()
+	if err != nil {
+		return CPUReport{Usage: 0.001}, err
+	}
+	report := CPUReport{Usage: detail.Usage}
+	if report.Usage <= 0.001 {
+		report.Usage = 0.001
 	}
-	data["cpu"] = map[string]interface{}{
-		"usage": cpuUsage,
+	times := cpuTimesReport(detail.Times)
+	report.Times = &times
+	for _, core := range detail.Cores {
+		report.Cores = append(report.Cores, CPUCoreReport{
+			Name:  core.Name,
+			Usage: core.Usage,
+			Times: cpuTimesReport(core.Times),
+		})
 	}
+	return report, nil
+}
 
-	ram := monitoring.Ram()
-	data["ram"] = map[string]interface{}{
-		"total": ram.Total,
-		"used":  ram.Used,
+func cpuTimesReport(t monitoring.CpuTimesPercent) CPUTimesReport {
+	return CPUTimesReport{
+		User:      t.User,
+		System:    t.System,
+		Nice:      t.Nice,
+		Iowait:    t.Iowait,
+		Irq:       t.Irq,
+		Softirq:   t.Softirq,
+		Steal:     t.Steal,
+		Guest:     t.Guest,
+		GuestNice: t.GuestNice,
+		Idle:      t.Idle,
 	}
+}
+
+type memoryCollector struct{}
 
+func (memoryCollector) Name() string  { return "memory" }
+func (memoryCollector) Enabled() bool { return true }
+func (memoryCollector) Collect(ctx context.Context) (Metrics, error) {
+	ram, detail, err := monitoring.RamDetail()
 	swap := monitoring.Swap()
-	data["swap"] = map[string]interface{}{
-		"total": swap.Total,
-		"used":  swap.Used,
+	metrics := memoryMetrics{
+		RAM:  MemoryReport{Total: ram.Total, Used: ram.Used},
+		Swap: MemoryReport{Total: swap.Total, Used: swap.Used},
 	}
-	load := monitoring.Load()
-	data["load"] = map[string]interface{}{
-		"load1":  load.Load1,
-		"load5":  load.Load5,
-		"load15": load.Load15,
+	if err != nil {
+		return metrics, err
 	}
+	metrics.Detail = memoryDetailReport(detail)
+	return metrics, nil
+}
 
-	disk := monitoring.Disk()
-	data["disk"] = map[string]interface{}{
-		"total": disk.Total,
-		"used":  disk.Used,
+func memoryDetailReport(d monitoring.MemoryDetail) *MemoryDetailReport {
+	report := &MemoryDetailReport{
+		Available:       d.Available,
+		Free:            d.Free,
+		Buffers:         d.Buffers,
+		Cached:          d.Cached,
+		Shared:          d.Shared,
+		Slab:            d.Slab,
+		SlabReclaimable: d.SlabReclaimable,
+		Dirty:           d.Dirty,
+		CommittedAS:     d.CommittedAS,
+		CommitLimit:     d.CommitLimit,
+		HugePagesTotal:  d.HugePagesTotal,
+		HugePagesFree:   d.HugePagesFree,
+		HugePageSize:    d.HugePageSize,
 	}
-
-	totalUp, totalDown, networkUp, networkDown, err := monitoring.NetworkSpeed()
-	if err != nil {
-		message += fmt.Sprintf("failed to get network speed: %v\n", err)
+	for _, z := range d.Zram {
+		report.Zram = append(report.Zram, ZramReport{
+			Name:          z.Name,
+			OrigDataSize:  z.OrigDataSize,
+			ComprDataSize: z.ComprDataSize,
+			MemUsedTotal:  z.MemUsedTotal,
+			Ratio:         z.Ratio,
+		})
+	}
+	if d.Zswap != nil {
+		report.Zswap = &ZswapReport{Stored: d.Zswap.Stored, PoolSize: d.Zswap.PoolSize, Ratio: d.Zswap.Ratio}
 	}
-	data["network"] = map[string]interface{}{
-		"up":        networkUp,
-		"down":      networkDown,
-		"totalUp":   totalUp,
-		"totalDown": totalDown,
+	return report
+}
+
+type loadCollector struct{}
+
+func (loadCollector) Name() string  { return "load" }
+func (loadCollector) Enabled() bool { return true }
+func (loadCollector) Collect(ctx context.Context) (Metrics, error) {
+	load := monitoring.Load()
+	return LoadReport{Load1: load.Load1, Load5: load.Load5, Load15: load.Load15}, nil
+}
+
+type diskCollector struct{}
+
+func (diskCollector) Name() string  { return "disk" }
+func (diskCollector) Enabled() bool { return true }
+func (diskCollector) Collect(ctx context.Context) (Metrics, error) {
+	mounts := monitoring.Mountpoints()
+	disk := monitoring.SumMountpoints(mounts)
+	report := DiskReport{Total: disk.Total, Used: disk.Used}
+	for _, m := range mounts {
+		report.Mountpoints = append(report.Mountpoints, MountpointReport{
+			Mountpoint:  m.Mountpoint,
+			Device:      m.Device,
+			Fstype:      m.Fstype,
+			Total:       m.Total,
+			Used:        m.Used,
+			Free:        m.Free,
+			InodesTotal: m.InodesTotal,
+			InodesUsed:  m.InodesUsed,
+			InodesFree:  m.InodesFree,
+		})
 	}
+	return report, nil
+}
 
-	tcpCount, udpCount, err := monitoring.ConnectionsCount()
-	if err != nil {
-		message += fmt.Sprintf("failed to get connections: %v\n", err)
+type networkCollector struct{}
+
+func (networkCollector) Name() string  { return "network" }
+func (networkCollector) Enabled() bool { return true }
+func (networkCollector) Collect(ctx context.Context) (Metrics, error) {
+	totalUp, totalDown, networkUp, networkDown, err := monitoring.NetworkSpeed()
+	report := NetworkReport{
+		Up:        networkUp,
+		Down:      networkDown,
+		TotalUp:   totalUp,
+		TotalDown: totalDown,
+	}
+	interfaces, ifaceErr := monitoring.NetworkInterfaces()
+	for _, iface := range interfaces {
+		report.Interfaces = append(report.Interfaces, InterfaceReport{
+			Name:        iface.Name,
+			State:       iface.State,
+			Up:          iface.UpSpeed,
+			Down:        iface.DownSpeed,
+			PacketsUp:   iface.PacketsSent,
+			PacketsDown: iface.PacketsRecv,
+			TotalUp:     iface.TotalUp,
+			TotalDown:   iface.TotalDown,
+			ErrIn:       iface.Errin,
+			ErrOut:      iface.Errout,
+			DropIn:      iface.Dropin,
+			DropOut:     iface.Dropout,
+		})
 	}
-	data["connections"] = map[string]interface{}{
-		"tcp": tcpCount,
-		"udp": udpCount,
+	if err == nil {
+		err = ifaceErr
 	}
+	return report, err
+}
+
+type connectionsCollector struct{}
+
+func (connectionsCollector) Name() string  { return "connections" }
+func (connectionsCollector) Enabled() bool { return true }
+func (connectionsCollector) Collect(ctx context.Context) (Metrics, error) {
+	info, err := monitoring.Connections()
+	return ConnectionsReport{TCP: info.TCP, UDP: info.UDP, TCPStates: info.TCPStates}, err
+}
+
+type uptimeCollector struct{}
 
+func (uptimeCollector) Name() string  { return "uptime" }
+func (uptimeCollector) Enabled() bool { return true }
+func (uptimeCollector) Collect(ctx context.Context) (Metrics, error) {
 	uptime, err := monitoring.Uptime()
-	if err != nil {
-		message += fmt.Sprintf("failed to get uptime: %v\n", err)
-	}
-	data["uptime"] = uptime
+	return uptimeMetrics(uptime), err
+}
+
+type processCountCollector struct{}
 
-	processcount := monitoring.ProcessCount()
-	data["process"] = processcount
+func (processCountCollector) Name() string  { return "process" }
+func (processCountCollector) Enabled() bool { return true }
+func (processCountCollector) Collect(ctx context.Context) (Metrics, error) {
+	return processCountMetrics(monitoring.ProcessCount()), nil
+}
 
-	data["message"] = message
+// CollectReport 采集一次实时数据。各采集器并行执行且不阻塞等待，
+// 速率类数据由与上一次采集的差值计算得出。
+func CollectReport() Report {
+	report := Report{SchemaVersion: SchemaVersion}
+	runCollectors(registered(), currentCollectorConfig(), &report)
+	return report
+}
 
-	s, err := json.Marshal(data)
+// GenerateReport 采集一次实时数据并编码为 JSON
+func GenerateReport() []byte {
+	s, err := json.Marshal(CollectReport())
 	if err != nil {
 		log.Println("Failed to marshal data:", err)
 	}
@@ -0,0 +1,56 @@
+package monitoring
+
+import (
+	"context"
+	"runtime"
+
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+func (m *PSIReport) Apply(r *Report) { r.PSI = m }
+
+type psiCollector struct{}
+
+func (psiCollector) Name() string { return "psi" }
+
+// Enabled PSI 仅 Linux 4.20 及以上提供
+func (psiCollector) Enabled() bool { return runtime.GOOS == "linux" }
+
+// Collect 不可用的数据源直接省略，不作为错误上报
+func (psiCollector) Collect(ctx context.Context) (Metrics, error) {
+	report := &PSIReport{
+		System: pressureReport(monitoring.SystemPressure()),
+		Cgroup: pressureReport(monitoring.CgroupPressure()),
+	}
+	if report.System == nil && report.Cgroup == nil {
+		return nil, nil
+	}
+	return report, nil
+}
+
+func pressureReport(info monitoring.PressureInfo) *PressureReport {
+	if info.Empty() {
+		return nil
+	}
+	return &PressureReport{
+		CPU:    pressureStatReport(info.CPU),
+		Memory: pressureStatReport(info.Memory),
+		IO:     pressureStatReport(info.IO),
+	}
+}
+
+func pressureStatReport(stat *monitoring.PressureStat) *PressureStatReport {
+	if stat == nil {
+		return nil
+	}
+	report := &PressureStatReport{Some: pressureLineReport(stat.Some)}
+	if stat.Full != nil {
+		full := pressureLineReport(*stat.Full)
+		report.Full = &full
+	}
+	return report
+}
+
+func pressureLineReport(line monitoring.PressureLine) PressureLineReport {
+	return PressureLineReport{Avg10: line.Avg10, Avg60: line.Avg60, Avg300: line.Avg300, Total: line.Total}
+}
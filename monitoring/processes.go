@@ -0,0 +1,89 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+func (m *TopProcessesReport) Apply(r *Report) { r.TopProcesses = m }
+
+type topProcessesCollector struct{}
+
+func (topProcessesCollector) Name() string  { return "top_processes" }
+func (topProcessesCollector) Enabled() bool { return flags.Current().TopProcesses > 0 }
+
+// Interval 遍历所有进程开销较大，默认按 --top-processes-interval 运行
+func (topProcessesCollector) Interval() time.Duration {
+	return time.Duration(flags.Current().TopProcessesInterval) * time.Second
+}
+
+func (topProcessesCollector) Collect(ctx context.Context) (Metrics, error) {
+	patterns, err := redactPatterns()
+	byCPU, byMemory, ok, topErr := monitoring.TopProcesses(ctx, flags.Current().TopProcesses, patterns)
+	if topErr != nil {
+		return nil, topErr
+	}
+	// 首次采集只记录 CPU 时间基准，返回 nil 使下一次上报立即再采集
+	if !ok {
+		return nil, err
+	}
+	return &TopProcessesReport{ByCPU: processReports(byCPU), ByMemory: processReports(byMemory)}, err
+}
+
+func processReports(procs []monitoring.ProcessInfo) []ProcessReport {
+	reports := make([]ProcessReport, 0, len(procs))
+	for _, p := range procs {
+		reports = append(reports, ProcessReport{
+			PID:     p.PID,
+			Name:    p.Name,
+			User:    p.User,
+			Cmdline: p.Cmdline,
+			CPU:     p.CPU,
+			RSS:     p.RSS,
+			Threads: p.Threads,
+			FDs:     p.FDs,
+		})
+	}
+	return reports
+}
+
+var (
+	redactMu     sync.Mutex
+	redactSource string
+	redactCache  []*regexp.Regexp
+	redactErr    error
+	redactLoaded bool
+)
+
+// redactPatterns 编译 --top-processes-redact，参数未变化时复用上次结果。
+// 有无法编译的表达式时整条命令行都会被隐藏，避免因配置错误泄露敏感信息。
+func redactPatterns() ([]*regexp.Regexp, error) {
+	cfg := flags.Current()
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	if redactLoaded && redactSource == cfg.TopProcessesRedact {
+		return redactCache, redactErr
+	}
+	redactSource, redactLoaded = cfg.TopProcessesRedact, true
+	redactCache, redactErr = nil, nil
+	for _, expr := range strings.Split(cfg.TopProcessesRedact, ";") {
+		if expr = strings.TrimSpace(expr); expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			redactCache = []*regexp.Regexp{regexp.MustCompile(`(?s).+`)}
+			redactErr = fmt.Errorf("invalid --top-processes-redact %q, hiding all command lines: %w", expr, err)
+			break
+		}
+		redactCache = append(redactCache, re)
+	}
+	return redactCache, redactErr
+}
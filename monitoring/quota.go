@@ -0,0 +1,343 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+const (
+	// quotaHookTimeout 阈值钩子命令的最长运行时间
+	quotaHookTimeout = time.Minute
+	// quotaCheckInterval 上报循环之外检查配额阈值的间隔
+	quotaCheckInterval = 30 * time.Second
+)
+
+// QuotaWarning 本计费周期的流量达到配额阈值时推送的事件
+type QuotaWarning struct {
+	// Type 固定为 quota_warning
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	// Direction 计入配额的方向：up、down 或 sum
+	Direction string `json:"direction"`
+	// Threshold 触发的阈值，百分比
+	Threshold float64 `json:"threshold"`
+	// Percent 当前用量占配额的百分比
+	Percent float64 `json:"percent"`
+	// Used/Limit 单位字节
+	Used        uint64    `json:"used"`
+	Limit       uint64    `json:"limit"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// quotaConfig 由 --traffic-quota* 参数解析得到的配额配置
+type quotaConfig struct {
+	limit      uint64
+	direction  string
+	thresholds []float64
+	hook       string
+}
+
+func parseQuotaConfig() (quotaConfig, error) {
+	cfg := quotaConfig{direction: strings.ToLower(strings.TrimSpace(flags.Current().TrafficQuotaDirection)), hook: flags.Current().TrafficQuotaHook}
+	limit, err := parseByteSize(flags.Current().TrafficQuota)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid --traffic-quota: %w", err)
+	}
+	cfg.limit = limit
+	switch cfg.direction {
+	case "up", "down", "sum":
+	default:
+		return cfg, fmt.Errorf("invalid --traffic-quota-direction %q, must be up, down or sum", flags.Current().TrafficQuotaDirection)
+	}
+	for _, item := range strings.Split(flags.Current().TrafficQuotaThresholds, ",") {
+		item = strings.TrimSuffix(strings.TrimSpace(item), "%")
+		if item == "" {
+			continue
+		}
+		threshold, err := strconv.ParseFloat(item, 64)
+		if err != nil || threshold <= 0 {
+			return cfg, fmt.Errorf("invalid quota threshold %q", item)
+		}
+		cfg.thresholds = append(cfg.thresholds, threshold)
+	}
+	sort.Float64s(cfg.thresholds)
+	return cfg, nil
+}
+
+// parseByteSize 解析 1TB、500GiB、1.5T 或纯数字字节数。
+// KB/MB/GB/TB/PB 按 1000 进位，KiB/MiB/GiB/TiB/PiB 及单字母 K/M/G/T/P 按 1024 进位。
+func parseByteSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	value, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	unit := strings.ToUpper(strings.TrimSpace(s[i:]))
+	var multiplier float64
+	switch unit {
+	case "", "B":
+		multiplier = 1
+	default:
+		exp := strings.Index("KMGTP", unit[:1]) + 1
+		if exp == 0 {
+			return 0, fmt.Errorf("invalid size unit %q", s[i:])
+		}
+		switch unit[1:] {
+		case "B":
+			multiplier = math.Pow(1000, float64(exp))
+		case "", "IB":
+			multiplier = math.Pow(1024, float64(exp))
+		default:
+			return 0, fmt.Errorf("invalid size unit %q", s[i:])
+		}
+	}
+	return uint64(value * multiplier), nil
+}
+
+// quotaUsed 返回计入配额的用量
+func quotaUsed(direction string, usage monitoring.TrafficUsage) uint64 {
+	switch direction {
+	case "up":
+		return usage.Up
+	case "down":
+		return usage.Down
+	default:
+		return usage.Up + usage.Down
+	}
+}
+
+// quotaState 记录本计费周期内已触发的阈值，持久化后重启也不会重复触发
+type quotaState struct {
+	mu     sync.Mutex
+	path   string
+	loaded bool
+
+	PeriodStart time.Time            `json:"period_start"`
+	Fired       map[string]time.Time `json:"fired"`
+}
+
+var quotaTracker quotaState
+
+// quotaStatePath 已触发阈值的保存位置，与流量账本放在同一目录
+func quotaStatePath() string {
+	return filepath.Join(filepath.Dir(monitoring.TrafficLedgerPath()), "traffic-quota.json")
+}
+
+// check 返回本次新达到的阈值对应的事件，并记录为已触发
+func (s *quotaState) check(cfg quotaConfig, usage monitoring.TrafficUsage, now time.Time) ([]QuotaWarning, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		s.load()
+	}
+	if !s.PeriodStart.Equal(usage.PeriodStart) {
+		s.PeriodStart = usage.PeriodStart
+		s.Fired = map[string]time.Time{}
+	}
+
+	used := quotaUsed(cfg.direction, usage)
+	percent := float64(used) / float64(cfg.limit) * 100
+	var warnings []QuotaWarning
+	for _, threshold := range cfg.thresholds {
+		key := fmt.Sprintf("%s:%g", cfg.direction, threshold)
+		if percent < threshold {
+			continue
+		}
+		if _, fired := s.Fired[key]; fired {
+			continue
+		}
+		s.Fired[key] = now
+		warnings = append(warnings, QuotaWarning{
+			Type:        "quota_warning",
+			Timestamp:   now,
+			Direction:   cfg.direction,
+			Threshold:   threshold,
+			Percent:     percent,
+			Used:        used,
+			Limit:       cfg.limit,
+			PeriodStart: usage.PeriodStart,
+			PeriodEnd:   usage.PeriodEnd,
+		})
+	}
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+	return warnings, s.save()
+}
+
+func (s *quotaState) load() {
+	s.loaded = true
+	s.Fired = map[string]time.Time{}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		log.Println("Failed to parse traffic quota state:", err)
+	}
+	if s.Fired == nil {
+		s.Fired = map[string]time.Time{}
+	}
+}
+
+func (s *quotaState) save() error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	// 与流量账本相同，先写临时文件再重命名，避免写入中途崩溃截断文件后阈值重复触发
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to save traffic quota state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to save traffic quota state: %w", err)
+	}
+	return nil
+}
+
+// runQuotaHook 在后台运行阈值钩子命令，事件内容通过 KOMARI_QUOTA_* 环境变量传入
+func runQuotaHook(command string, w QuotaWarning) {
+	ctx, cancel := context.WithTimeout(context.Background(), quotaHookTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-Command", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), quotaHookEnv(w)...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		log.Printf("Traffic quota hook for %g%% failed: %v, output: %s", w.Threshold, err, strings.TrimSpace(output.String()))
+		return
+	}
+	log.Printf("Traffic quota hook for %g%% finished", w.Threshold)
+}
+
+func quotaHookEnv(w QuotaWarning) []string {
+	return []string{
+		"KOMARI_QUOTA_DIRECTION=" + w.Direction,
+		"KOMARI_QUOTA_THRESHOLD=" + strconv.FormatFloat(w.Threshold, 'f', -1, 64),
+		"KOMARI_QUOTA_PERCENT=" + strconv.FormatFloat(w.Percent, 'f', 2, 64),
+		"KOMARI_QUOTA_USED=" + strconv.FormatUint(w.Used, 10),
+		"KOMARI_QUOTA_LIMIT=" + strconv.FormatUint(w.Limit, 10),
+		"KOMARI_QUOTA_PERIOD_START=" + w.PeriodStart.Format(time.RFC3339),
+		"KOMARI_QUOTA_PERIOD_END=" + w.PeriodEnd.Format(time.RFC3339),
+	}
+}
+
+func (m *TrafficQuotaReport) Apply(r *Report) { r.TrafficQuota = m }
+
+type quotaCollector struct{}
+
+func (quotaCollector) Name() string { return "traffic_quota" }
+func (quotaCollector) Enabled() bool {
+	cfg := flags.Current()
+	return strings.TrimSpace(cfg.TrafficQuota) != "" && strings.TrimSpace(cfg.TrafficQuota) != "0"
+}
+func (quotaCollector) Collect(ctx context.Context) (Metrics, error) {
+	report, err := checkTrafficQuota(monitoring.MonthlyTraffic, time.Now())
+	if report == nil {
+		return nil, err
+	}
+	return report, err
+}
+
+// WatchTrafficQuota 定期检查流量配额阈值，独立于上报循环运行，
+// 断线期间同样会推送事件并运行钩子，事件在连接恢复后发送。
+func WatchTrafficQuota() {
+	ticker := time.NewTicker(quotaCheckInterval)
+	defer ticker.Stop()
+	watchTrafficQuota(ticker.C, monitoring.MonthlyTraffic)
+}
+
+func watchTrafficQuota(tick <-chan time.Time, usage func() (monitoring.TrafficUsage, error)) {
+	lastErr := ""
+	for now := range tick {
+		if !(quotaCollector{}).Enabled() {
+			continue
+		}
+		_, err := checkTrafficQuota(usage, now)
+		// 配置错误会在每次检查时重复出现，只在变化时记录
+		if msg := fmt.Sprint(err); err != nil && msg != lastErr {
+			log.Println("Failed to check traffic quota:", err)
+			lastErr = msg
+		} else if err == nil {
+			lastErr = ""
+		}
+	}
+}
+
+// checkTrafficQuota 读取本周期的流量用量，新达到阈值时推送事件并运行钩子
+func checkTrafficQuota(usageFn func() (monitoring.TrafficUsage, error), now time.Time) (*TrafficQuotaReport, error) {
+	if flags.Current().MonthRotate == 0 {
+		return nil, fmt.Errorf("--traffic-quota requires --month-rotate")
+	}
+	cfg, err := parseQuotaConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.limit == 0 {
+		return nil, nil
+	}
+	usage, err := usageFn()
+	if err != nil {
+		return nil, err
+	}
+	used := quotaUsed(cfg.direction, usage)
+	report := &TrafficQuotaReport{
+		Direction:   cfg.direction,
+		Used:        used,
+		Limit:       cfg.limit,
+		Percent:     float64(used) / float64(cfg.limit) * 100,
+		PeriodStart: usage.PeriodStart,
+		PeriodEnd:   usage.PeriodEnd,
+	}
+
+	quotaTracker.mu.Lock()
+	if path := quotaStatePath(); quotaTracker.path != path {
+		quotaTracker.path, quotaTracker.loaded = path, false
+	}
+	quotaTracker.mu.Unlock()
+	warnings, err := quotaTracker.check(cfg, usage, now)
+	for _, w := range warnings {
+		log.Printf("Traffic quota reached %g%% (%s %d/%d bytes)", w.Threshold, w.Direction, w.Used, w.Limit)
+		emitEvent(w)
+	}
+	if cfg.hook != "" && len(warnings) > 0 {
+		// 同时达到多个阈值时按从低到高的顺序依次运行
+		go func() {
+			for _, w := range warnings {
+				runQuotaHook(cfg.hook, w)
+			}
+		}()
+	}
+	return report, err
+}
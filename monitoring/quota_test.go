@@ -0,0 +1,161 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		{"", 0},
+		{"1048576", 1 << 20},
+		{"1TB", 1e12},
+		{"500GiB", 500 << 30},
+		{"1.5T", 3 << 39},
+		{"2 gb", 2e9},
+		{"10k", 10 << 10},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"TB", "1XB", "1GBB", "abc"} {
+		if _, err := parseByteSize(bad); err == nil {
+			t.Errorf("parseByteSize(%q) should fail", bad)
+		}
+	}
+}
+
+func TestQuotaStateFiresEachThresholdOncePerPeriod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic-quota.json")
+	cfg := quotaConfig{limit: 1000, direction: "sum", thresholds: []float64{80, 95, 100}}
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	usage := func(up, down uint64, start time.Time) monitoring.TrafficUsage {
+		return monitoring.TrafficUsage{Up: up, Down: down, PeriodStart: start, PeriodEnd: start.AddDate(0, 1, 0)}
+	}
+	now := march.Add(time.Hour)
+
+	state := &quotaState{path: path}
+	if w, _ := state.check(cfg, usage(100, 600, march), now); len(w) != 0 {
+		t.Fatalf("fired below thresholds: %+v", w)
+	}
+	// 一次跨过两个阈值时逐个触发
+	w, err := state.check(cfg, usage(300, 660, march), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w) != 2 || w[0].Threshold != 80 || w[1].Threshold != 95 || w[1].Used != 960 || w[1].Type != "quota_warning" {
+		t.Fatalf("unexpected warnings: %+v", w)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary state file left behind: %v", err)
+	}
+
+	// 重启后从文件恢复，已触发的阈值不再触发
+	state = &quotaState{path: path}
+	if w, _ := state.check(cfg, usage(300, 670, march), now); len(w) != 0 {
+		t.Fatalf("thresholds fired again after reload: %+v", w)
+	}
+	if w, _ := state.check(cfg, usage(400, 700, march), now); len(w) != 1 || w[0].Threshold != 100 {
+		t.Fatalf("expected the 100%% threshold, got %+v", w)
+	}
+
+	// 新的计费周期重新计算
+	april := march.AddDate(0, 1, 0)
+	if w, _ := state.check(cfg, usage(900, 0, april), april); len(w) != 1 || w[0].Threshold != 80 {
+		t.Fatalf("expected 80%% in the new period, got %+v", w)
+	}
+	// 只统计上传时下载不计入配额
+	up := quotaConfig{limit: 1000, direction: "up", thresholds: []float64{50}}
+	if w, _ := state.check(up, usage(100, 900, april), april); len(w) != 0 {
+		t.Fatalf("download counted against an upload quota: %+v", w)
+	}
+}
+
+func TestRunQuotaHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook test uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "hook.out")
+	runQuotaHook(`echo "$KOMARI_QUOTA_THRESHOLD $KOMARI_QUOTA_DIRECTION $KOMARI_QUOTA_USED" > `+out, QuotaWarning{
+		Direction: "down",
+		Threshold: 95,
+		Used:      950,
+		Limit:     1000,
+	})
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "95 down 950" {
+		t.Errorf("hook output = %q", got)
+	}
+}
+
+func TestWatchTrafficQuotaWithoutConnection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook test uses sh")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "hook.out")
+	original := *flags.Current()
+	defer flags.Update(func(c *flags.Config) { *c = original })
+	flags.Update(func(c *flags.Config) {
+		c.MonthRotate = 1
+		c.TrafficLedger = filepath.Join(dir, "traffic-ledger.json")
+		c.TrafficQuota = "1000"
+		c.TrafficQuotaDirection = "sum"
+		c.TrafficQuotaThresholds = "80"
+		c.TrafficQuotaHook = "echo $KOMARI_QUOTA_THRESHOLD > " + out
+	})
+	for len(events) > 0 {
+		<-events
+	}
+
+	// 不经过上报循环与连接，仅由定时检查触发
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var used atomic.Uint64
+	used.Store(500)
+	usage := func() (monitoring.TrafficUsage, error) {
+		return monitoring.TrafficUsage{Up: used.Load(), PeriodStart: start, PeriodEnd: start.AddDate(0, 1, 0)}, nil
+	}
+	tick := make(chan time.Time)
+	go watchTrafficQuota(tick, usage)
+	defer close(tick)
+	tick <- start.Add(time.Hour)
+	used.Store(850)
+	tick <- start.Add(2 * time.Hour)
+
+	select {
+	case ev := <-Events():
+		if w, ok := ev.(QuotaWarning); !ok || w.Threshold != 80 || w.Used != 850 {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no quota event emitted")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(out)
+		if err == nil && strings.TrimSpace(string(data)) == "80" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hook did not run: %q, %v", data, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,427 @@
+package monitoring
+
+import "time"
+
+// SchemaVersion 上报数据结构的版本。新增可选字段不改变版本，
+// 删除、重命名字段或修改字段含义时递增。
+const SchemaVersion = 1
+
+// Report 通过 WebSocket 周期性上报的实时数据
+type Report struct {
+	SchemaVersion int          `json:"schema_version"`
+	CPU           CPUReport    `json:"cpu"`
+	RAM           MemoryReport `json:"ram"`
+	Swap          MemoryReport `json:"swap"`
+	Load          LoadReport   `json:"load"`
+	Disk          DiskReport   `json:"disk"`
+	// MemoryDetail 内存构成明细，面板可据此自行定义“已用”
+	MemoryDetail *MemoryDetailReport `json:"memory_detail,omitempty"`
+	// PSI Linux 压力停滞信息，内核不支持时省略
+	PSI *PSIReport `json:"psi,omitempty"`
+	// DiskIO 各块设备的读写情况
+	DiskIO      []DiskIOReport    `json:"disk_io,omitempty"`
+	Network     NetworkReport     `json:"network"`
+	Connections ConnectionsReport `json:"connections"`
+	// TrafficQuota 设置了 --traffic-quota 时本计费周期的配额用量
+	TrafficQuota *TrafficQuotaReport `json:"traffic_quota,omitempty"`
+	// Sensors 温度与风扇传感器，没有可用传感器时省略
+	Sensors *SensorsReport `json:"sensors,omitempty"`
+	// Uptime 系统运行时间，单位秒
+	Uptime uint64 `json:"uptime"`
+	// Process 进程数
+	Process int `json:"process"`
+	// TopProcesses 设置了 --top-processes 时资源占用最高的进程
+	TopProcesses *TopProcessesReport `json:"top_processes,omitempty"`
+	// Watch 设置了 --watch 时各监视项的状态
+	Watch []WatchReport `json:"watch,omitempty"`
+	// Systemd 由 systemd 启动的系统上失败的单元与 --systemd-units 指定单元的状态
+	Systemd *SystemdReport `json:"systemd,omitempty"`
+	// GPU 安装了 nvidia-smi 或 rocm-smi 时各 GPU 的使用情况
+	GPU []GPUReport `json:"gpu,omitempty"`
+	// Containers 设置了 --container-socket 时 Docker/Podman 中的容器
+	Containers []ContainerReport `json:"containers,omitempty"`
+	// Cgroup 在容器中运行时 agent 所在 cgroup 的限制与限流情况
+	Cgroup *CgroupReport `json:"cgroup,omitempty"`
+	// Custom 通过 Register 注册的第三方采集器数据，按采集器名称存放
+	Custom map[string]interface{} `json:"custom,omitempty"`
+	// Message 采集过程中的错误信息，每行一条
+	Message string `json:"message"`
+}
+
+type CPUReport struct {
+	// Usage 总体 CPU 使用率，百分比
+	Usage float64 `json:"usage"`
+	// Times 总体 CPU 时间占比
+	Times *CPUTimesReport `json:"times,omitempty"`
+	// Cores 每个逻辑核心的使用情况
+	Cores []CPUCoreReport `json:"cores,omitempty"`
+}
+
+// CPUTimesReport 上报间隔内各类 CPU 时间的占比，百分比。
+// Linux 下 guest/guest_nice 已包含在 user/nice 中。
+type CPUTimesReport struct {
+	User      float64 `json:"user"`
+	System    float64 `json:"system"`
+	Nice      float64 `json:"nice"`
+	Iowait    float64 `json:"iowait"`
+	Irq       float64 `json:"irq"`
+	Softirq   float64 `json:"softirq"`
+	Steal     float64 `json:"steal"`
+	Guest     float64 `json:"guest"`
+	GuestNice float64 `json:"guest_nice"`
+	Idle      float64 `json:"idle"`
+}
+
+type CPUCoreReport struct {
+	// Name 核心名称，例如 cpu0
+	Name  string         `json:"name"`
+	Usage float64        `json:"usage"`
+	Times CPUTimesReport `json:"times"`
+}
+
+// MemoryReport 内存或交换空间用量，单位字节
+type MemoryReport struct {
+	Total uint64 `json:"total"`
+	Used  uint64 `json:"used"`
+}
+
+// MemoryDetailReport 内存构成明细，除大页数量外单位字节，平台不提供的项为 0
+type MemoryDetailReport struct {
+	Available       uint64 `json:"available"`
+	Free            uint64 `json:"free"`
+	Buffers         uint64 `json:"buffers"`
+	Cached          uint64 `json:"cached"`
+	Shared          uint64 `json:"shared"`
+	Slab            uint64 `json:"slab"`
+	SlabReclaimable uint64 `json:"slab_reclaimable"`
+	Dirty           uint64 `json:"dirty"`
+	CommittedAS     uint64 `json:"committed_as"`
+	CommitLimit     uint64 `json:"commit_limit"`
+	// HugePagesTotal/HugePagesFree 大页数量，单位页
+	HugePagesTotal uint64 `json:"hugepages_total"`
+	HugePagesFree  uint64 `json:"hugepages_free"`
+	// HugePageSize 单个大页的大小，单位字节
+	HugePageSize uint64 `json:"hugepage_size"`
+	// Zram 各 zram 设备的压缩情况
+	Zram []ZramReport `json:"zram,omitempty"`
+	// Zswap 未启用 zswap 时省略
+	Zswap *ZswapReport `json:"zswap,omitempty"`
+}
+
+type ZramReport struct {
+	Name string `json:"name"`
+	// OrigDataSize/ComprDataSize 压缩前后的数据量
+	OrigDataSize  uint64 `json:"orig_data_size"`
+	ComprDataSize uint64 `json:"compr_data_size"`
+	// MemUsedTotal 实际占用的内存，含分配器开销
+	MemUsedTotal uint64 `json:"mem_used_total"`
+	// Ratio 压缩比，没有数据时为 0
+	Ratio float64 `json:"ratio"`
+}
+
+type ZswapReport struct {
+	// Stored 存入 zswap 的页面压缩前大小
+	Stored uint64 `json:"stored"`
+	// PoolSize 压缩池占用的内存
+	PoolSize uint64 `json:"pool_size"`
+	// Ratio 压缩比，没有数据时为 0
+	Ratio float64 `json:"ratio"`
+}
+
+type LoadReport struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// DiskReport 所有统计挂载点的容量总和，单位字节
+type DiskReport struct {
+	Total uint64 `json:"total"`
+	Used  uint64 `json:"used"`
+	// Mountpoints 参与统计的各挂载点
+	Mountpoints []MountpointReport `json:"mountpoints,omitempty"`
+}
+
+// MountpointReport 单个挂载点的容量（字节）与 inode 用量
+type MountpointReport struct {
+	Mountpoint  string `json:"mountpoint"`
+	Device      string `json:"device"`
+	Fstype      string `json:"fstype"`
+	Total       uint64 `json:"total"`
+	Used        uint64 `json:"used"`
+	Free        uint64 `json:"free"`
+	InodesTotal uint64 `json:"inodes_total"`
+	InodesUsed  uint64 `json:"inodes_used"`
+	InodesFree  uint64 `json:"inodes_free"`
+}
+
+// DiskIOReport 单个块设备在上报间隔内的平均读写情况
+type DiskIOReport struct {
+	Name string `json:"name"`
+	// ReadSpeed/WriteSpeed 单位字节/秒
+	ReadSpeed  uint64  `json:"read_speed"`
+	WriteSpeed uint64  `json:"write_speed"`
+	ReadIOPS   float64 `json:"read_iops"`
+	WriteIOPS  float64 `json:"write_iops"`
+	// Await 每个 I/O 请求的平均耗时，单位毫秒
+	Await float64 `json:"await"`
+	// Util 设备忙碌时间占比，百分比
+	Util float64 `json:"util"`
+}
+
+type NetworkReport struct {
+	// Up/Down 上传与下载速率，单位字节/秒
+	Up   uint64 `json:"up"`
+	Down uint64 `json:"down"`
+	// TotalUp/TotalDown 累计流量，单位字节；启用 --month-rotate 时为当前计费周期的流量
+	TotalUp   uint64 `json:"totalUp"`
+	TotalDown uint64 `json:"totalDown"`
+	// Interfaces 参与统计的各网卡
+	Interfaces []InterfaceReport `json:"interfaces,omitempty"`
+}
+
+// InterfaceReport 单个网卡的流量与状态
+type InterfaceReport struct {
+	Name string `json:"name"`
+	// State 链路状态：up、no-carrier、down 或 unknown
+	State string `json:"state"`
+	// Up/Down 上传与下载速率，单位字节/秒
+	Up   uint64 `json:"up"`
+	Down uint64 `json:"down"`
+	// PacketsUp/PacketsDown 收发包速率，单位包/秒
+	PacketsUp   float64 `json:"packets_up"`
+	PacketsDown float64 `json:"packets_down"`
+	// 以下为网卡启动以来的累计值
+	TotalUp   uint64 `json:"totalUp"`
+	TotalDown uint64 `json:"totalDown"`
+	ErrIn     uint64 `json:"errin"`
+	ErrOut    uint64 `json:"errout"`
+	DropIn    uint64 `json:"dropin"`
+	DropOut   uint64 `json:"dropout"`
+}
+
+// TrafficQuotaReport 本计费周期的流量配额用量
+type TrafficQuotaReport struct {
+	// Direction 计入配额的方向：up、down 或 sum
+	Direction string `json:"direction"`
+	// Used/Limit 单位字节
+	Used        uint64    `json:"used"`
+	Limit       uint64    `json:"limit"`
+	Percent     float64   `json:"percent"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// PSIReport 系统全局及 agent 所在 cgroup 的压力停滞信息
+type PSIReport struct {
+	System *PressureReport `json:"system,omitempty"`
+	Cgroup *PressureReport `json:"cgroup,omitempty"`
+}
+
+// PressureReport cpu/memory/io 的停滞情况，不可用的资源省略
+type PressureReport struct {
+	CPU    *PressureStatReport `json:"cpu,omitempty"`
+	Memory *PressureStatReport `json:"memory,omitempty"`
+	IO     *PressureStatReport `json:"io,omitempty"`
+}
+
+type PressureStatReport struct {
+	// Some 至少一个任务停滞的时间占比
+	Some PressureLineReport `json:"some"`
+	// Full 所有任务同时停滞的时间占比
+	Full *PressureLineReport `json:"full,omitempty"`
+}
+
+type PressureLineReport struct {
+	// Avg10/Avg60/Avg300 最近 10/60/300 秒的百分比
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	// Total 累计停滞时间，单位微秒
+	Total uint64 `json:"total"`
+}
+
+// SensorsReport 温度与风扇传感器读数
+type SensorsReport struct {
+	Temperatures []TemperatureReport `json:"temperatures,omitempty"`
+	Fans         []FanReport         `json:"fans,omitempty"`
+}
+
+// TemperatureReport 单个温度传感器，单位摄氏度
+type TemperatureReport struct {
+	// Sensor hwmon 设备名或 thermal zone 类型
+	Sensor      string  `json:"sensor"`
+	Label       string  `json:"label"`
+	Temperature float64 `json:"temperature"`
+	// High/Critical 告警与临界温度，传感器未提供时省略
+	High     float64 `json:"high,omitempty"`
+	Critical float64 `json:"critical,omitempty"`
+}
+
+// FanReport 单个风扇
+type FanReport struct {
+	Sensor string `json:"sensor"`
+	Label  string `json:"label"`
+	RPM    uint64 `json:"rpm"`
+}
+
+type ConnectionsReport struct {
+	TCP int `json:"tcp"`
+	UDP int `json:"udp"`
+	// TCPStates 按状态分组的 TCP 连接数，如 ESTABLISHED、TIME_WAIT、CLOSE_WAIT
+	TCPStates map[string]int `json:"tcp_states,omitempty"`
+}
+
+// ListeningPortReport 正在监听的端口
+type ListeningPortReport struct {
+	// Protocol tcp、tcp6、udp 或 udp6
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	Port     uint32 `json:"port"`
+	PID      int32  `json:"pid"`
+	// Process 所属进程名，无法获取时为空
+	Process string `json:"process"`
+}
+
+// TopProcessesReport 按 CPU 使用率与 RSS 排序的前 N 个进程
+type TopProcessesReport struct {
+	ByCPU    []ProcessReport `json:"by_cpu"`
+	ByMemory []ProcessReport `json:"by_memory"`
+}
+
+type ProcessReport struct {
+	PID  int32  `json:"pid"`
+	Name string `json:"name"`
+	User string `json:"user"`
+	// Cmdline 已隐藏敏感参数并截断
+	Cmdline string `json:"cmdline"`
+	// CPU 采集区间内的 CPU 使用率，100 表示占满一个核心
+	CPU float64 `json:"cpu"`
+	// RSS 常驻内存，单位字节
+	RSS     uint64 `json:"rss"`
+	Threads int32  `json:"threads"`
+	FDs     int32  `json:"fds"`
+}
+
+// WatchReport 监视列表中一项的状态
+type WatchReport struct {
+	Name string `json:"name"`
+	// Kind name、regex、pidfile 或 unit
+	Kind string `json:"kind"`
+	// Status running、stopped，检查出错时为 unknown
+	Status    string `json:"status"`
+	Instances int    `json:"instances"`
+	// Restarts agent 启动以来观察到的重启次数
+	Restarts int `json:"restarts"`
+	// CPU 所有实例的 CPU 使用率之和，100 表示占满一个核心
+	CPU float64 `json:"cpu"`
+	// RSS 所有实例的常驻内存之和，单位字节
+	RSS uint64 `json:"rss"`
+}
+
+type SystemdReport struct {
+	// Failed 处于 failed 状态的单元
+	Failed []SystemdUnitReport `json:"failed"`
+	// Units --systemd-units 指定的单元
+	Units []SystemdUnitReport `json:"units,omitempty"`
+}
+
+type SystemdUnitReport struct {
+	Name string `json:"name"`
+	// LoadState loaded、not-found、masked 等，查询失败时为 unknown
+	LoadState string `json:"load_state"`
+	// ActiveState active、failed、activating、inactive 等，查询失败时为 unknown
+	ActiveState string `json:"active_state"`
+	SubState    string `json:"sub_state"`
+	// Result 服务上一次结束的原因，非 service 单元为空
+	Result string `json:"result,omitempty"`
+	// Restarts systemd 自动重启的次数
+	Restarts uint32 `json:"restarts"`
+	// ExitCode 主进程上一次的退出码或终止信号
+	ExitCode int32 `json:"exit_code"`
+}
+
+type ContainerReport struct {
+	// ID 容器 ID 的前 12 位
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// State created、running、paused、restarting、exited、dead 等
+	State string `json:"state"`
+	// Health healthy、unhealthy、starting，未配置健康检查时为空
+	Health       string `json:"health"`
+	RestartCount int    `json:"restart_count"`
+	// CPU 采集区间内的 CPU 使用率，100 表示占满一个核心
+	CPU float64 `json:"cpu"`
+	// MemUsed/MemLimit 单位字节，MemUsed 不含可回收的页缓存
+	MemUsed  uint64 `json:"mem_used"`
+	MemLimit uint64 `json:"mem_limit"`
+	// NetRx/NetTx 容器启动以来的累计流量，单位字节
+	NetRx uint64 `json:"net_rx"`
+	NetTx uint64 `json:"net_tx"`
+}
+
+// GPUReport 单个 GPU 的使用情况，驱动工具不提供的项为 0
+type GPUReport struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Vendor nvidia 或 amd
+	Vendor string `json:"vendor"`
+	// Utilization GPU 使用率，百分比
+	Utilization float64 `json:"utilization"`
+	// MemUsed/MemTotal 显存，单位字节
+	MemUsed  uint64 `json:"mem_used"`
+	MemTotal uint64 `json:"mem_total"`
+	// Temperature 单位摄氏度
+	Temperature float64 `json:"temperature"`
+	// PowerDraw 功耗，单位瓦
+	PowerDraw float64            `json:"power_draw"`
+	Processes []GPUProcessReport `json:"processes,omitempty"`
+}
+
+// GPUProcessReport 使用 GPU 的进程，MemUsed 为占用的显存，单位字节
+type GPUProcessReport struct {
+	PID     int32  `json:"pid"`
+	Name    string `json:"name"`
+	MemUsed uint64 `json:"mem_used"`
+}
+
+// CgroupReport 容器的资源限制。未设置 --ignore-cgroup-limits 时，
+// ram 与 cpu 中的总量和使用率已按该限制计算。
+type CgroupReport struct {
+	// Version cgroup 版本，1 或 2
+	Version int `json:"version"`
+	// MemLimit/MemUsed 单位字节，MemLimit 为 0 表示不限制，MemUsed 不含可回收的页缓存
+	MemLimit uint64 `json:"mem_limit"`
+	MemUsed  uint64 `json:"mem_used"`
+	// CPUQuota 以核数计的 CPU 配额，0 表示不限制
+	CPUQuota float64 `json:"cpu_quota"`
+	// NrPeriods/NrThrottled 累计调度周期数及其中被限流的周期数
+	NrPeriods   uint64 `json:"nr_periods"`
+	NrThrottled uint64 `json:"nr_throttled"`
+	// ThrottledTime 累计被限流时间，单位微秒
+	ThrottledTime uint64 `json:"throttled_time"`
+}
+
+// BasicInfo 通过 HTTP 周期性上传的基础信息
+type BasicInfo struct {
+	SchemaVersion int    `json:"schema_version"`
+	CPUName       string `json:"cpu_name"`
+	CPUCores      int    `json:"cpu_cores"`
+	Arch          string `json:"arch"`
+	OS            string `json:"os"`
+	// KernelVersion 为空时省略，兼容不接受该字段的旧版服务端
+	KernelVersion string `json:"kernel_version,omitempty"`
+	IPv4          string `json:"ipv4"`
+	IPv6          string `json:"ipv6"`
+	// MemTotal/SwapTotal/DiskTotal 单位字节
+	MemTotal       uint64 `json:"mem_total"`
+	SwapTotal      uint64 `json:"swap_total"`
+	DiskTotal      uint64 `json:"disk_total"`
+	GPUName        string `json:"gpu_name"`
+	Virtualization string `json:"virtualization"`
+	// Version agent 版本
+	Version string `json:"version"`
+	// ListeningPorts 正在监听的端口及所属进程，为空时省略
+	ListeningPorts []ListeningPortReport `json:"listening_ports,omitempty"`
+}
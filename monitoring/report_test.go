@@ -0,0 +1,202 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// sampleReport 覆盖所有字段的固定数据，用于锁定上报格式
+func sampleReport() Report {
+	return Report{
+		SchemaVersion: SchemaVersion,
+		CPU: CPUReport{
+			Usage: 12.5,
+			Times: &CPUTimesReport{User: 8, System: 3, Iowait: 1, Steal: 0.5, Idle: 87.5},
+			Cores: []CPUCoreReport{
+				{Name: "cpu0", Usage: 20, Times: CPUTimesReport{User: 15, System: 5, Idle: 80}},
+				{Name: "cpu1", Usage: 5, Times: CPUTimesReport{User: 1, System: 1, Iowait: 2, Steal: 1, Idle: 95}},
+			},
+		},
+		RAM:  MemoryReport{Total: 8 << 30, Used: 3 << 30},
+		Swap: MemoryReport{Total: 2 << 30, Used: 1 << 20},
+		Load: LoadReport{Load1: 0.5, Load5: 0.25, Load15: 0.125},
+		Disk: DiskReport{
+			Total: 100 << 30,
+			Used:  40 << 30,
+			Mountpoints: []MountpointReport{{
+				Mountpoint:  "/",
+				Device:      "/dev/vda1",
+				Fstype:      "ext4",
+				Total:       100 << 30,
+				Used:        40 << 30,
+				Free:        55 << 30,
+				InodesTotal: 6553600,
+				InodesUsed:  412345,
+				InodesFree:  6141255,
+			}},
+		},
+		MemoryDetail: &MemoryDetailReport{
+			Available:       6 << 30,
+			Free:            1 << 30,
+			Buffers:         256 << 20,
+			Cached:          4 << 30,
+			Shared:          64 << 20,
+			Slab:            512 << 20,
+			SlabReclaimable: 384 << 20,
+			Dirty:           2 << 20,
+			CommittedAS:     5 << 30,
+			CommitLimit:     6 << 30,
+			HugePagesTotal:  0,
+			HugePagesFree:   0,
+			HugePageSize:    2 << 20,
+			Zram: []ZramReport{
+				{Name: "zram0", OrigDataSize: 400 << 20, ComprDataSize: 100 << 20, MemUsedTotal: 105 << 20, Ratio: 4},
+			},
+			Zswap: &ZswapReport{Stored: 30 << 20, PoolSize: 10 << 20, Ratio: 3},
+		},
+		PSI: &PSIReport{
+			System: &PressureReport{
+				CPU: &PressureStatReport{Some: PressureLineReport{Avg10: 1.15, Avg60: 2.45, Avg300: 2.07, Total: 69986993}},
+				Memory: &PressureStatReport{
+					Some: PressureLineReport{Avg10: 0.5, Avg60: 0.1, Avg300: 0.05, Total: 1200},
+					Full: &PressureLineReport{Avg10: 0.2, Avg60: 0.02, Avg300: 0.01, Total: 300},
+				},
+			},
+			Cgroup: &PressureReport{
+				IO: &PressureStatReport{
+					Some: PressureLineReport{Avg10: 3.5, Avg60: 1.25, Avg300: 0.4, Total: 5400},
+					Full: &PressureLineReport{Avg10: 2, Avg60: 0.75, Avg300: 0.2, Total: 2100},
+				},
+			},
+		},
+		DiskIO: []DiskIOReport{
+			{Name: "vda", ReadSpeed: 4096, WriteSpeed: 65536, ReadIOPS: 1, WriteIOPS: 16, Await: 0.75, Util: 2.5},
+		},
+		Network: NetworkReport{
+			Up:        1024,
+			Down:      2048,
+			TotalUp:   1 << 30,
+			TotalDown: 2 << 30,
+			Interfaces: []InterfaceReport{{
+				Name: "eth0", State: "up", Up: 1024, Down: 2048, PacketsUp: 12, PacketsDown: 20.5,
+				TotalUp: 1 << 30, TotalDown: 2 << 30, ErrIn: 1, DropIn: 3,
+			}},
+		},
+		TrafficQuota: &TrafficQuotaReport{
+			Direction:   "sum",
+			Used:        3 << 30,
+			Limit:       1 << 40,
+			Percent:     0.29296875,
+			PeriodStart: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			PeriodEnd:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		Connections: ConnectionsReport{
+			TCP: 42,
+			UDP: 7,
+			TCPStates: map[string]int{
+				"ESTABLISHED": 30,
+				"TIME_WAIT":   8,
+				"LISTEN":      4,
+			},
+		},
+		Sensors: &SensorsReport{
+			Temperatures: []TemperatureReport{
+				{Sensor: "coretemp", Label: "Package id 0", Temperature: 52, High: 84, Critical: 100},
+				{Sensor: "nvme", Label: "Composite", Temperature: 38.85},
+			},
+			Fans: []FanReport{{Sensor: "nct6775", Label: "CPU Fan", RPM: 1250}},
+		},
+		Uptime:  86400,
+		Process: 128,
+		TopProcesses: &TopProcessesReport{
+			ByCPU: []ProcessReport{
+				{PID: 2048, Name: "ffmpeg", User: "media", Cmdline: "ffmpeg -i input.mkv -c:v libx264 output.mp4", CPU: 385.5, RSS: 512 << 20, Threads: 17, FDs: 12},
+			},
+			ByMemory: []ProcessReport{
+				{PID: 1024, Name: "postgres", User: "postgres", Cmdline: "postgres -D /var/lib/postgresql/data --password=***", CPU: 2.5, RSS: 2 << 30, Threads: 1, FDs: 48},
+			},
+		},
+		Watch: []WatchReport{
+			{Name: "nginx", Kind: "name", Status: "running", Instances: 5, Restarts: 1, CPU: 3.5, RSS: 48 << 20},
+			{Name: "redis.service", Kind: "unit", Status: "stopped"},
+		},
+		Systemd: &SystemdReport{
+			Failed: []SystemdUnitReport{
+				{Name: "backup.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed", Result: "exit-code", Restarts: 0, ExitCode: 1},
+			},
+			Units: []SystemdUnitReport{
+				{Name: "nginx.service", LoadState: "loaded", ActiveState: "active", SubState: "running", Result: "success", Restarts: 2},
+			},
+		},
+		GPU: []GPUReport{
+			{Index: 0, Name: "NVIDIA GeForce RTX 4090", Vendor: "nvidia", Utilization: 87, MemUsed: 20480 << 20, MemTotal: 24564 << 20, Temperature: 71, PowerDraw: 386.45,
+				Processes: []GPUProcessReport{{PID: 40213, Name: "/usr/bin/python3", MemUsed: 18944 << 20}}},
+		},
+		Containers: []ContainerReport{
+			{ID: "4f66ad9a0b2e", Name: "web", Image: "nginx:1.25", State: "running", Health: "healthy", RestartCount: 2, CPU: 12.5, MemUsed: 100 << 20, MemLimit: 512 << 20, NetRx: 1 << 30, NetTx: 256 << 20},
+			{ID: "9c1e2d3f4a5b", Name: "job", Image: "busybox", State: "exited"},
+		},
+		Cgroup:  &CgroupReport{Version: 2, MemLimit: 2 << 30, MemUsed: 612 << 20, CPUQuota: 1.5, NrPeriods: 86400, NrThrottled: 1200, ThrottledTime: 35000000},
+		Message: "",
+	}
+}
+
+func sampleBasicInfo() BasicInfo {
+	return BasicInfo{
+		SchemaVersion:  SchemaVersion,
+		CPUName:        "Example CPU @ 3.00GHz",
+		CPUCores:       8,
+		Arch:           "amd64",
+		OS:             "Debian GNU/Linux 12 (bookworm)",
+		KernelVersion:  "6.1.0-18-amd64",
+		IPv4:           "192.0.2.1",
+		IPv6:           "2001:db8::1",
+		MemTotal:       8 << 30,
+		SwapTotal:      2 << 30,
+		DiskTotal:      100 << 30,
+		GPUName:        "None",
+		Virtualization: "kvm",
+		Version:        "1.0.0",
+		ListeningPorts: []ListeningPortReport{
+			{Protocol: "tcp", Address: "0.0.0.0", Port: 22, PID: 512, Process: "sshd"},
+			{Protocol: "udp6", Address: "::", Port: 53, PID: 640, Process: "systemd-resolved"},
+		},
+	}
+}
+
+func TestGolden(t *testing.T) {
+	tests := map[string]interface{}{
+		"report.golden.json":     sampleReport(),
+		"basic_info.golden.json": sampleBasicInfo(),
+	}
+	for file, v := range tests {
+		t.Run(file, func(t *testing.T) {
+			got, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+			path := filepath.Join("testdata", file)
+			if *update {
+				if err := os.WriteFile(path, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to generate)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s changed, verify the protocol change and run: go test ./monitoring -update\ngot:\n%s", file, got)
+			}
+		})
+	}
+}
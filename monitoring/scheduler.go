@@ -0,0 +1,110 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+type collectResult struct {
+	index    int
+	metrics  Metrics
+	err      error
+	timedOut bool
+	running  bool
+	// background 采集器的超时长于报告等待时间，超出等待后在后台继续运行
+	background bool
+}
+
+// collectTimeout 报告等待单个采集器的时间，保证一次采集在上报间隔内完成
+func collectTimeout() time.Duration {
+	interval := flags.Current().Interval
+	if interval < 1 {
+		interval = 1
+	}
+	return time.Duration(interval * 0.9 * float64(time.Second))
+}
+
+// runCollectors 并行执行所有启用的采集器，每个单独超时。
+// 超时、仍在运行或未到采集间隔的采集器沿用上一次的结果。
+// 超时长于 collectTimeout 的采集器不阻塞报告，在后台完成后供之后的报告使用。
+func runCollectors(collectors []*scheduledCollector, cfg collectorConfig, report *Report) {
+	timeout := collectTimeout()
+	now := time.Now()
+	results := make(chan collectResult, len(collectors))
+	pending := 0
+	byIndex := make([]collectResult, len(collectors))
+	skipped := map[int]bool{}
+
+	for i, c := range collectors {
+		if _, off := cfg.disabled[c.Name()]; off || !c.Enabled() {
+			skipped[i] = true
+			continue
+		}
+		own := c.timeout(cfg, timeout)
+		background := own > timeout
+		if last, fresh := c.cached(c.interval(cfg), now); fresh {
+			byIndex[i] = collectResult{index: i, metrics: last, background: background}
+			continue
+		}
+		if !c.start() {
+			byIndex[i] = collectResult{index: i, running: true, background: background}
+			continue
+		}
+		pending++
+		go func(i int, c *scheduledCollector) {
+			ctx, cancel := context.WithTimeout(context.Background(), own)
+			done := make(chan collectResult, 1)
+			go func() {
+				defer cancel()
+				m, err := c.Collect(ctx)
+				c.finish(m, err, time.Now())
+				done <- collectResult{index: i, metrics: m, err: err, background: background}
+			}()
+			wait := time.NewTimer(timeout)
+			defer wait.Stop()
+			select {
+			case r := <-done:
+				results <- r
+			case <-wait.C:
+				results <- collectResult{index: i, timedOut: true, background: background}
+			}
+		}(i, c)
+	}
+
+	for ; pending > 0; pending-- {
+		r := <-results
+		byIndex[r.index] = r
+	}
+
+	message := ""
+	for i, c := range collectors {
+		if skipped[i] {
+			continue
+		}
+		r := byIndex[i]
+		// 后台完成的采集没有等待者，其错误在之后的报告中上报
+		lateErr := c.takeErr()
+		switch {
+		case r.background && (r.running || r.timedOut):
+			r.metrics, _ = c.cached(0, now)
+		case r.running:
+			message += fmt.Sprintf("%s collector is still running, reusing previous result\n", c.Name())
+			r.metrics, _ = c.cached(0, now)
+		case r.timedOut:
+			message += fmt.Sprintf("%s collector timed out, reusing previous result\n", c.Name())
+			r.metrics, _ = c.cached(0, now)
+		case r.err != nil:
+			message += fmt.Sprintf("failed to get %s: %v\n", c.Name(), r.err)
+		}
+		if r.background && r.err == nil && lateErr != nil {
+			message += fmt.Sprintf("failed to get %s: %v\n", c.Name(), lateErr)
+		}
+		if r.metrics != nil {
+			r.metrics.Apply(report)
+		}
+	}
+	report.Message += message
+}
@@ -0,0 +1,273 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// funcCollector 测试用采集器
+type funcCollector struct {
+	name    string
+	enabled bool
+	collect func(ctx context.Context) (Metrics, error)
+}
+
+func (c funcCollector) Name() string  { return c.name }
+func (c funcCollector) Enabled() bool { return c.enabled }
+func (c funcCollector) Collect(ctx context.Context) (Metrics, error) {
+	return c.collect(ctx)
+}
+
+func schedule(cs ...funcCollector) []*scheduledCollector {
+	out := make([]*scheduledCollector, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, &scheduledCollector{Collector: c})
+	}
+	return out
+}
+
+func TestRunCollectorsParallelWithTimeouts(t *testing.T) {
+	oldInterval := flags.Current().Interval
+	flags.Update(func(c *flags.Config) { c.Interval = 0.05 })
+	defer flags.Update(func(c *flags.Config) { c.Interval = oldInterval })
+
+	release := make(chan struct{})
+	defer close(release)
+
+	collectors := schedule(
+		funcCollector{name: "uptime", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+			return uptimeMetrics(42), nil
+		}},
+		funcCollector{name: "network", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+			return NetworkReport{TotalUp: 7}, errors.New("boom")
+		}},
+		funcCollector{name: "slow", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+			<-release
+			return processCountMetrics(-1), nil
+		}},
+	)
+	slow := collectors[2]
+	// 模拟上一次采集成功的结果
+	slow.last = processCountMetrics(99)
+
+	cfg, _ := parseCollectorConfig("", "")
+	start := time.Now()
+	var report Report
+	runCollectors(collectors, cfg, &report)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("runCollectors took %v, want it bounded by the timeout", elapsed)
+	}
+	if report.Uptime != 42 {
+		t.Errorf("Uptime = %d, want 42", report.Uptime)
+	}
+	if report.Network.TotalUp != 7 {
+		t.Error("partial result of a failing collector was not applied")
+	}
+	if report.Process != 99 {
+		t.Errorf("Process = %d, want previous result 99", report.Process)
+	}
+	if !strings.Contains(report.Message, "failed to get network: boom") {
+		t.Errorf("missing error message: %q", report.Message)
+	}
+	if !strings.Contains(report.Message, "slow collector timed out") {
+		t.Errorf("missing timeout message: %q", report.Message)
+	}
+
+	// 仍在运行的采集器不会被重复启动
+	report = Report{}
+	runCollectors([]*scheduledCollector{slow}, cfg, &report)
+	if !strings.Contains(report.Message, "slow collector is still running") || report.Process != 99 {
+		t.Errorf("unexpected report for a running collector: %+v", report)
+	}
+}
+
+func TestRunCollectorsConfig(t *testing.T) {
+	var calls atomic.Int32
+	collectors := schedule(
+		funcCollector{name: "counted", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+			return uptimeMetrics(calls.Add(1)), nil
+		}},
+		funcCollector{name: "off", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+			t.Error("disabled collector was run")
+			return nil, nil
+		}},
+		funcCollector{name: "unavailable", enabled: false, collect: func(ctx context.Context) (Metrics, error) {
+			t.Error("collector reporting Enabled() == false was run")
+			return nil, nil
+		}},
+		funcCollector{name: "custom", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+			return CustomMetrics{Name: "custom", Value: map[string]int{"queue": 3}}, nil
+		}},
+	)
+
+	cfg, err := parseCollectorConfig("off, missing", "counted=1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		var report Report
+		runCollectors(collectors, cfg, &report)
+		if report.Uptime != 1 {
+			t.Errorf("run %d: Uptime = %d, want cached 1", i, report.Uptime)
+		}
+		if report.Custom["custom"] == nil {
+			t.Errorf("run %d: custom metrics missing", i)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("collector ran %d times within its interval, want 1", calls.Load())
+	}
+}
+
+func TestEmptyResultClearsCache(t *testing.T) {
+	var calls atomic.Int32
+	gone := &scheduledCollector{Collector: funcCollector{name: "gone", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+		switch calls.Add(1) {
+		case 1:
+			return uptimeMetrics(5), nil
+		case 2:
+			return nil, errors.New("temporary failure")
+		default:
+			return nil, nil
+		}
+	}}}
+
+	cfg, _ := parseCollectorConfig("", "gone=0s")
+	var report Report
+	runCollectors([]*scheduledCollector{gone}, cfg, &report)
+	if report.Uptime != 5 {
+		t.Fatalf("Uptime = %d, want 5", report.Uptime)
+	}
+	// 采集失败时保留上一次的结果，供之后超时的采集沿用
+	runCollectors([]*scheduledCollector{gone}, cfg, &Report{})
+	if last, _ := gone.cached(0, time.Now()); last == nil {
+		t.Error("failed collection dropped the previous result")
+	}
+	// 成功但没有数据时不再沿用旧的结果
+	runCollectors([]*scheduledCollector{gone}, cfg, &Report{})
+	if last, _ := gone.cached(0, time.Now()); last != nil {
+		t.Errorf("cached result = %+v, want it cleared", last)
+	}
+
+	// 空结果同样遵循采集间隔
+	cfg, _ = parseCollectorConfig("", "gone=1h")
+	for i := 0; i < 2; i++ {
+		report = Report{}
+		runCollectors([]*scheduledCollector{gone}, cfg, &report)
+		if report.Uptime != 0 {
+			t.Errorf("run %d: Uptime = %d, want the stale value gone", i, report.Uptime)
+		}
+	}
+	if calls.Load() != 3 {
+		t.Errorf("collector ran %d times, want the empty result cached for its interval", calls.Load())
+	}
+}
+
+// slowCollector 通过 IntervalCollector 声明默认采集间隔
+type slowCollector struct{ funcCollector }
+
+func (slowCollector) Interval() time.Duration { return time.Hour }
+
+func TestIntervalCollectorDefault(t *testing.T) {
+	var calls atomic.Int32
+	slow := &scheduledCollector{Collector: slowCollector{funcCollector{name: "slow", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+		return uptimeMetrics(calls.Add(1)), nil
+	}}}}
+
+	cfg, _ := parseCollectorConfig("", "")
+	for i := 0; i < 2; i++ {
+		runCollectors([]*scheduledCollector{slow}, cfg, &Report{})
+	}
+	if calls.Load() != 1 {
+		t.Errorf("collector ran %d times within its default interval, want 1", calls.Load())
+	}
+
+	// --collector-intervals 覆盖默认间隔
+	cfg, _ = parseCollectorConfig("", "slow=0s")
+	runCollectors([]*scheduledCollector{slow}, cfg, &Report{})
+	if calls.Load() != 2 {
+		t.Errorf("collector ran %d times, want the override to force a run", calls.Load())
+	}
+}
+
+// timeoutCollector 通过 TimeoutCollector 声明长于上报间隔的超时
+type timeoutCollector struct {
+	funcCollector
+	timeout time.Duration
+}
+
+func (c timeoutCollector) Timeout() time.Duration { return c.timeout }
+
+func TestSlowCollectorFinishesInBackground(t *testing.T) {
+	oldInterval := flags.Current().Interval
+	flags.Update(func(c *flags.Config) { c.Interval = 0.05 })
+	defer flags.Update(func(c *flags.Config) { c.Interval = oldInterval })
+
+	// 采集耗时超过报告等待时间，但在自身间隔之内
+	slow := &scheduledCollector{Collector: slowCollector{funcCollector{name: "slow", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+		select {
+		case <-time.After(1200 * time.Millisecond):
+			return uptimeMetrics(7), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}}}}
+	// 超过自身超时的采集器，错误在之后的报告中上报
+	hung := &scheduledCollector{Collector: timeoutCollector{funcCollector{name: "hung", enabled: true, collect: func(ctx context.Context) (Metrics, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}, 1100 * time.Millisecond}}
+
+	cfg, _ := parseCollectorConfig("", "")
+	start := time.Now()
+	var report Report
+	runCollectors([]*scheduledCollector{slow, hung}, cfg, &report)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("runCollectors took %v, want it bounded by the report timeout", elapsed)
+	}
+	if report.Message != "" {
+		t.Errorf("collectors within their own timeout should not be reported: %q", report.Message)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	report = Report{}
+	runCollectors([]*scheduledCollector{slow, hung}, cfg, &report)
+	if report.Uptime != 7 {
+		t.Errorf("Uptime = %d, want the result collected in the background", report.Uptime)
+	}
+	if !strings.Contains(report.Message, "failed to get hung: context deadline exceeded") {
+		t.Errorf("missing error of the background run: %q", report.Message)
+	}
+}
+
+func TestParseCollectorConfig(t *testing.T) {
+	cfg, err := parseCollectorConfig("disk,, load ", "disk=30s, connections = 5s,bad,process=x")
+	if err == nil {
+		t.Error("expected error for malformed intervals")
+	}
+	if len(cfg.disabled) != 2 {
+		t.Errorf("disabled = %v, want disk and load", cfg.disabled)
+	}
+	if cfg.intervals["disk"] != 30*time.Second || cfg.intervals["connections"] != 5*time.Second || len(cfg.intervals) != 2 {
+		t.Errorf("intervals = %v", cfg.intervals)
+	}
+}
+
+func TestBuiltinCollectorsRegistered(t *testing.T) {
+	names := strings.Join(CollectorNames(), ",")
+	if names != "cpu,memory,load,psi,cgroup,disk,disk_io,network,traffic_quota,connections,sensors,gpu,uptime,process,top_processes,watch,systemd,containers" {
+		t.Errorf("CollectorNames() = %s", names)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate name should panic")
+		}
+	}()
+	Register(cpuCollector{})
+}
@@ -0,0 +1,148 @@
+// Package schema 根据上报数据的 Go 结构体生成 JSON Schema。
+//
+// 生成的文件位于本目录下，修改 monitoring.Report 或 monitoring.BasicInfo 后运行
+//
+//	go test ./monitoring/schema -update
+//
+// 重新生成。
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/monitoring"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema JSON Schema 中本项目用到的子集
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Report 返回实时上报数据的 JSON Schema
+func Report() *Schema {
+	s := Generate(monitoring.Report{}, "komari-agent report")
+	s.Properties["schema_version"].Const = monitoring.SchemaVersion
+	return s
+}
+
+// BasicInfo 返回基础信息的 JSON Schema
+func BasicInfo() *Schema {
+	s := Generate(monitoring.BasicInfo{}, "komari-agent basic info")
+	s.Properties["schema_version"].Const = monitoring.SchemaVersion
+	return s
+}
+
+// Generate 通过反射为 v 的类型生成 JSON Schema，具名结构体放入 $defs 中引用
+func Generate(v interface{}, title string) *Schema {
+	g := &generator{defs: map[string]*Schema{}}
+	root := g.object(reflect.TypeOf(v))
+	root.Schema = draft
+	root.Title = title
+	if len(g.defs) > 0 {
+		root.Defs = g.defs
+	}
+	return root
+}
+
+// Marshal 将 Schema 编码为带缩进的 JSON
+func (s *Schema) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			// 先占位，避免递归类型无限展开
+			g.defs[t.Name()] = &Schema{}
+			*g.defs[t.Name()] = *g.object(t)
+		}
+		return &Schema{Ref: "#/$defs/" + t.Name()}
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, s)
+	return s
+}
+
+// fields 按 encoding/json 的规则收集字段，匿名嵌入的结构体字段提升到外层
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, s)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
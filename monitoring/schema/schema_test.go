@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "regenerate the schema files")
+
+func TestSchemaFiles(t *testing.T) {
+	files := map[string]*Schema{
+		"report.schema.json":     Report(),
+		"basic_info.schema.json": BasicInfo(),
+	}
+	for file, s := range files {
+		t.Run(file, func(t *testing.T) {
+			got, err := s.Marshal()
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if *update {
+				if err := os.WriteFile(file, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("%v (run with -update to generate)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s is out of date, run: go test ./monitoring/schema -update", file)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	type inner struct {
+		Value float64 `json:"value"`
+	}
+	type sample struct {
+		Name     string            `json:"name"`
+		Count    uint64            `json:"count,omitempty"`
+		Items    []inner           `json:"items"`
+		Labels   map[string]string `json:"labels,omitempty"`
+		Ignored  string            `json:"-"`
+		internal int
+	}
+
+	s := Generate(sample{}, "sample")
+	if s.Type != "object" || s.Title != "sample" {
+		t.Fatalf("unexpected root: %+v", s)
+	}
+	if len(s.Properties) != 4 {
+		t.Errorf("got %d properties, want 4", len(s.Properties))
+	}
+	if got := s.Properties["count"]; got.Type != "integer" || got.Minimum == nil || *got.Minimum != 0 {
+		t.Errorf("count schema = %+v", got)
+	}
+	if got := s.Properties["items"]; got.Type != "array" || got.Items.Ref != "#/$defs/inner" {
+		t.Errorf("items schema = %+v", got)
+	}
+	if s.Defs["inner"].Properties["value"].Type != "number" {
+		t.Errorf("inner def = %+v", s.Defs["inner"])
+	}
+	if got := s.Properties["labels"]; got.AdditionalProperties.Type != "string" {
+		t.Errorf("labels schema = %+v", got)
+	}
+	if len(s.Required) != 2 || s.Required[0] != "name" || s.Required[1] != "items" {
+		t.Errorf("required = %v, want [name items]", s.Required)
+	}
+}
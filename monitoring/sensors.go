@@ -0,0 +1,40 @@
+package monitoring
+
+import (
+	"context"
+	"runtime"
+
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+func (m *SensorsReport) Apply(r *Report) { r.Sensors = m }
+
+type sensorsCollector struct{}
+
+func (sensorsCollector) Name() string { return "sensors" }
+
+// Enabled 只有 Linux 提供 hwmon/thermal sysfs 接口
+func (sensorsCollector) Enabled() bool { return runtime.GOOS == "linux" }
+func (sensorsCollector) Collect(ctx context.Context) (Metrics, error) {
+	sensors, err := monitoring.Sensors()
+	if err != nil {
+		return nil, err
+	}
+	if len(sensors.Temperatures) == 0 && len(sensors.Fans) == 0 {
+		return nil, nil
+	}
+	report := &SensorsReport{}
+	for _, t := range sensors.Temperatures {
+		report.Temperatures = append(report.Temperatures, TemperatureReport{
+			Sensor:      t.Sensor,
+			Label:       t.Label,
+			Temperature: t.Temperature,
+			High:        t.High,
+			Critical:    t.Critical,
+		})
+	}
+	for _, f := range sensors.Fans {
+		report.Fans = append(report.Fans, FanReport{Sensor: f.Sensor, Label: f.Label, RPM: f.RPM})
+	}
+	return report, nil
+}
@@ -0,0 +1,49 @@
+package monitoring
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+// systemdInterval systemd 单元状态的默认查询间隔
+const systemdInterval = 10 * time.Second
+
+func (m *SystemdReport) Apply(r *Report) { r.Systemd = m }
+
+type systemdCollector struct{}
+
+func (systemdCollector) Name() string { return "systemd" }
+
+// Enabled 只在由 systemd 启动的 Linux 系统上启用
+func (systemdCollector) Enabled() bool {
+	return runtime.GOOS == "linux" && monitoring.SystemdBooted()
+}
+func (systemdCollector) Interval() time.Duration { return systemdInterval }
+func (systemdCollector) Collect(ctx context.Context) (Metrics, error) {
+	info, err := monitoring.SystemdUnits(ctx, flags.Current().SystemdUnits)
+	if info.Failed == nil && info.Units == nil && err != nil {
+		return nil, err
+	}
+	// 部分单元查询失败时仍上报其余单元
+	return &SystemdReport{Failed: systemdUnitReports(info.Failed), Units: systemdUnitReports(info.Units)}, err
+}
+
+func systemdUnitReports(units []monitoring.SystemdUnit) []SystemdUnitReport {
+	reports := make([]SystemdUnitReport, 0, len(units))
+	for _, u := range units {
+		reports = append(reports, SystemdUnitReport{
+			Name:        u.Name,
+			LoadState:   u.LoadState,
+			ActiveState: u.ActiveState,
+			SubState:    u.SubState,
+			Result:      u.Result,
+			Restarts:    u.Restarts,
+			ExitCode:    u.ExitCode,
+		})
+	}
+	return reports
+}
@@ -0,0 +1,318 @@
+package monitoring
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// CgroupLimits agent 所在 cgroup 的资源限制与用量
+type CgroupLimits struct {
+	// Version cgroup 版本，1 或 2
+	Version int
+	// MemLimit 内存上限，单位字节，0 表示不限制
+	MemLimit uint64
+	// MemUsed 不含非活跃页缓存的内存用量，与 docker stats 一致
+	MemUsed uint64
+	// CPUQuota 以核数计的 CPU 配额，0 表示不限制
+	CPUQuota float64
+	// CPUUsage 累计 CPU 时间，单位微秒
+	CPUUsage uint64
+	// NrPeriods/NrThrottled 调度周期数及其中被限流的周期数
+	NrPeriods   uint64
+	NrThrottled uint64
+	// ThrottledTime 累计被限流时间，单位微秒
+	ThrottledTime uint64
+}
+
+var (
+	containerOnce sync.Once
+	inContainer   bool
+)
+
+// ContainerLimits 在容器（Docker、Podman、LXC 等）中运行时返回所在 cgroup 的限制与用量，
+// 不在容器中或读取失败时 ok 为 false
+func ContainerLimits() (limits CgroupLimits, ok bool) {
+	if runtime.GOOS != "linux" {
+		return limits, false
+	}
+	// 运行环境不会变化，只检测一次
+	containerOnce.Do(func() { inContainer = detectContainer() != "" })
+	if !inContainer {
+		return limits, false
+	}
+	limits, err := readCgroupLimits("/proc/self/cgroup", filepath.Join(sysfsRoot(), "fs", "cgroup"))
+	return limits, err == nil
+}
+
+// effectiveLimits 返回用于替换主机内存与 CPU 总量的 cgroup 限制，设置 --ignore-cgroup-limits 时 ok 为 false
+func effectiveLimits() (CgroupLimits, bool) {
+	if flags.Current().IgnoreCgroupLimits {
+		return CgroupLimits{}, false
+	}
+	return ContainerLimits()
+}
+
+// applyMemoryLimit 以容器的内存上限与用量替换主机的总量与已用
+func applyMemoryLimit(info RamInfo) RamInfo {
+	limits, ok := effectiveLimits()
+	if !ok || limits.MemUsed == 0 {
+		return info
+	}
+	if limits.MemLimit > 0 && limits.MemLimit < info.Total {
+		info.Total = limits.MemLimit
+	}
+	info.Used = min(limits.MemUsed, info.Total)
+	return info
+}
+
+// effectiveCPUCores 有 CPU 配额时返回向上取整的配额核数
+func effectiveCPUCores(hostCores int) int {
+	limits, ok := effectiveLimits()
+	if !ok || limits.CPUQuota <= 0 {
+		return hostCores
+	}
+	return int(math.Ceil(limits.EffectiveCores(hostCores)))
+}
+
+// cgroupCPUPercent 返回容器自上次调用以来占可用核数的 CPU 使用率，首次调用或不在容器中时 ok 为 false
+func (t *cgroupCPUTracker) cgroupCPUPercent() (float64, bool) {
+	limits, ok := effectiveLimits()
+	if !ok || limits.CPUUsage == 0 {
+		return 0, false
+	}
+	return t.percent(limits.CPUUsage, limits.EffectiveCores(runtime.NumCPU()), time.Now())
+}
+
+// readCgroupLimits 读取 cgroup 限制。限制取自所在 cgroup 到挂载点之间最严格的一层，
+// 因为 LXC 等环境中 agent 通常位于容器 cgroup 的子 cgroup 中。
+func readCgroupLimits(procCgroup, cgroupRoot string) (CgroupLimits, error) {
+	data, err := os.ReadFile(procCgroup)
+	if err != nil {
+		return CgroupLimits{}, err
+	}
+	// v1 中每行为 "<id>:<控制器列表>:<路径>"，控制器列表即挂载目录名，如 cpu,cpuacct；
+	// v2 为 "0::<路径>"，以空字符串作为键
+	paths, mounts := map[string]string{}, map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		paths[parts[1]] = parts[2]
+		for _, controller := range strings.Split(parts[1], ",") {
+			mounts[controller] = parts[1]
+		}
+	}
+
+	if path, ok := paths[""]; ok && fileExists(filepath.Join(cgroupRoot, "cgroup.controllers")) {
+		return readCgroupV2(cgroupRoot, cgroupDir(cgroupRoot, path)), nil
+	}
+	if _, ok := mounts["memory"]; ok {
+		return readCgroupV1(cgroupRoot, paths, mounts), nil
+	}
+	return CgroupLimits{}, fmt.Errorf("no usable cgroup hierarchy in %s", procCgroup)
+}
+
+// cgroupDir 返回 cgroup 对应的目录。未使用 cgroup 命名空间的容器内，
+// /proc/self/cgroup 中是宿主机视角的路径，而挂载点本身就是容器的 cgroup。
+func cgroupDir(mount, path string) string {
+	dir := filepath.Join(mount, filepath.FromSlash(path))
+	if _, err := os.Stat(dir); err != nil {
+		return mount
+	}
+	return dir
+}
+
+// ancestors 返回从 dir 到 mount 的各级目录
+func ancestors(mount, dir string) []string {
+	dirs := []string{dir}
+	for dir != mount && strings.HasPrefix(dir, mount) {
+		dir = filepath.Dir(dir)
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// usageDir 返回读取用量的目录：生效限制所在的一层，没有限制时为含有 file 的最上层。
+// LXC 中 agent 位于容器内的 /system.slice/komari-agent.service，只读所在 cgroup 会漏掉容器内的其他进程。
+func usageDir(dirs []string, limitDir, file string) string {
+	if limitDir != "" {
+		return limitDir
+	}
+	dir := dirs[0]
+	for _, d := range dirs {
+		if fileExists(filepath.Join(d, file)) {
+			dir = d
+		}
+	}
+	return dir
+}
+
+func readCgroupV2(mount, dir string) CgroupLimits {
+	limits := CgroupLimits{Version: 2}
+	dirs := ancestors(mount, dir)
+	// 限制相同时取更上层，用量覆盖整个容器
+	memLimitDir, cpuLimitDir := "", ""
+	for _, d := range dirs {
+		if v, err := strconv.ParseUint(readSysfsString(filepath.Join(d, "memory.max")), 10, 64); err == nil && (limits.MemLimit == 0 || v <= limits.MemLimit) {
+			limits.MemLimit, memLimitDir = v, d
+		}
+		// cpu.max 格式为 "<quota> <period>"，不限制时 quota 为 max
+		if fields := strings.Fields(readSysfsString(filepath.Join(d, "cpu.max"))); len(fields) == 2 {
+			if q := parseQuota(fields[0], fields[1]); q > 0 && (limits.CPUQuota == 0 || q <= limits.CPUQuota) {
+				limits.CPUQuota, cpuLimitDir = q, d
+			}
+		}
+	}
+	memDir := usageDir(dirs, memLimitDir, "memory.current")
+	current, _ := strconv.ParseUint(readSysfsString(filepath.Join(memDir, "memory.current")), 10, 64)
+	memStat := readKeyValues(filepath.Join(memDir, "memory.stat"))
+	limits.MemUsed = subtractCache(current, memStat["inactive_file"])
+
+	cpuStat := readKeyValues(filepath.Join(usageDir(dirs, cpuLimitDir, "cpu.stat"), "cpu.stat"))
+	limits.CPUUsage = cpuStat["usage_usec"]
+	limits.NrPeriods = cpuStat["nr_periods"]
+	limits.NrThrottled = cpuStat["nr_throttled"]
+	limits.ThrottledTime = cpuStat["throttled_usec"]
+	return limits
+}
+
+// v1 中内存上限为 PAGE_COUNTER_MAX 附近的极大值时表示不限制
+const cgroupV1Unlimited = math.MaxInt64 / 2
+
+func readCgroupV1(root string, paths, mounts map[string]string) CgroupLimits {
+	limits := CgroupLimits{Version: 1}
+	memMount := filepath.Join(root, mounts["memory"])
+	memDirs := ancestors(memMount, cgroupDir(memMount, paths[mounts["memory"]]))
+	memLimitDir := ""
+	for _, d := range memDirs {
+		if v, err := strconv.ParseUint(readSysfsString(filepath.Join(d, "memory.limit_in_bytes")), 10, 64); err == nil && v < cgroupV1Unlimited && (limits.MemLimit == 0 || v <= limits.MemLimit) {
+			limits.MemLimit, memLimitDir = v, d
+		}
+	}
+	memDir := usageDir(memDirs, memLimitDir, "memory.usage_in_bytes")
+	usage, _ := strconv.ParseUint(readSysfsString(filepath.Join(memDir, "memory.usage_in_bytes")), 10, 64)
+	memStat := readKeyValues(filepath.Join(memDir, "memory.stat"))
+	limits.MemUsed = subtractCache(usage, memStat["total_inactive_file"])
+
+	name, ok := mounts["cpu"]
+	if !ok {
+		return limits
+	}
+	cpuMount := filepath.Join(root, name)
+	cpuDirs := ancestors(cpuMount, cgroupDir(cpuMount, paths[name]))
+	cpuLimitDir := ""
+	for _, d := range cpuDirs {
+		quota := readSysfsString(filepath.Join(d, "cpu.cfs_quota_us"))
+		period := readSysfsString(filepath.Join(d, "cpu.cfs_period_us"))
+		if quota == "" || quota == "-1" {
+			continue
+		}
+		if q := parseQuota(quota, period); q > 0 && (limits.CPUQuota == 0 || q <= limits.CPUQuota) {
+			limits.CPUQuota, cpuLimitDir = q, d
+		}
+	}
+	cpuDir := usageDir(cpuDirs, cpuLimitDir, "cpu.stat")
+	// v1 的 cpuacct.usage 与 throttled_time 单位为纳秒；cpuacct 通常与 cpu 挂载在同一目录，
+	// 单独挂载时读取相同相对路径下的 cgroup
+	acctDir := cpuDir
+	if acct, ok := mounts["cpuacct"]; ok && acct != name {
+		acctMount := filepath.Join(root, acct)
+		acctDir = acctMount
+		if rel, err := filepath.Rel(cpuMount, cpuDir); err == nil {
+			acctDir = cgroupDir(acctMount, filepath.ToSlash(rel))
+		}
+	}
+	usageNs, _ := strconv.ParseUint(readSysfsString(filepath.Join(acctDir, "cpuacct.usage")), 10, 64)
+	limits.CPUUsage = usageNs / 1000
+	cpuStat := readKeyValues(filepath.Join(cpuDir, "cpu.stat"))
+	limits.NrPeriods = cpuStat["nr_periods"]
+	limits.NrThrottled = cpuStat["nr_throttled"]
+	limits.ThrottledTime = cpuStat["throttled_time"] / 1000
+	return limits
+}
+
+// parseQuota 将 quota/period 解析为核数，无法解析或不限制时返回 0
+func parseQuota(quota, period string) float64 {
+	q, err1 := strconv.ParseFloat(quota, 64)
+	p, err2 := strconv.ParseFloat(period, 64)
+	if err1 != nil || err2 != nil || q <= 0 || p <= 0 {
+		return 0
+	}
+	return q / p
+}
+
+func subtractCache(usage, inactive uint64) uint64 {
+	if inactive < usage {
+		return usage - inactive
+	}
+	return usage
+}
+
+// readKeyValues 读取 "key value" 格式的 memory.stat、cpu.stat
+func readKeyValues(path string) map[string]uint64 {
+	values := map[string]uint64{}
+	f, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values
+}
+
+// EffectiveCores 返回容器可用的核数：有 CPU 配额时取配额，否则为 hostCores
+func (l CgroupLimits) EffectiveCores(hostCores int) float64 {
+	if l.CPUQuota > 0 && l.CPUQuota < float64(hostCores) {
+		return l.CPUQuota
+	}
+	return float64(hostCores)
+}
+
+// cgroupCPUTracker 保存上一次读取的 cgroup 累计 CPU 时间
+type cgroupCPUTracker struct {
+	mu    sync.Mutex
+	usage uint64
+	at    time.Time
+}
+
+var (
+	cgroupUsageTracker  cgroupCPUTracker
+	cgroupDetailTracker cgroupCPUTracker
+)
+
+// percent 返回两次调用之间 cgroup 占可用核数的 CPU 使用率，首次调用返回 ok=false
+func (t *cgroupCPUTracker) percent(usage uint64, cores float64, now time.Time) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prevUsage, prevAt := t.usage, t.at
+	t.usage, t.at = usage, now
+	elapsed := now.Sub(prevAt).Microseconds()
+	if prevAt.IsZero() || usage < prevUsage || elapsed <= 0 || cores <= 0 {
+		return 0, false
+	}
+	percent := float64(usage-prevUsage) / (float64(elapsed) * cores) * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return percent, true
+}
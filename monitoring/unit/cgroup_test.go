@@ -0,0 +1,175 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadCgroupLimitsV2(t *testing.T) {
+	dir := t.TempDir()
+	procCgroup := filepath.Join(dir, "cgroup")
+	root := filepath.Join(dir, "fs")
+	writeSysfs(t, dir, map[string]string{
+		"cgroup": "0::/lxc.payload.web/init.scope",
+		// 限制设置在容器 cgroup 上，agent 位于其子 cgroup 中，用量按整个容器统计
+		"fs/cgroup.controllers":                        "cpu memory io",
+		"fs/lxc.payload.web/memory.max":                "2147483648",
+		"fs/lxc.payload.web/cpu.max":                   "150000 100000",
+		"fs/lxc.payload.web/memory.current":            "734003200",
+		"fs/lxc.payload.web/memory.stat":               "anon 524288000\nfile 209715200\ninactive_file 104857600",
+		"fs/lxc.payload.web/cpu.stat":                  "usage_usec 5000000\nuser_usec 3000000\nnr_periods 86400\nnr_throttled 1200\nthrottled_usec 35000000",
+		"fs/lxc.payload.web/init.scope/memory.max":     "max",
+		"fs/lxc.payload.web/init.scope/cpu.max":        "max 100000",
+		"fs/lxc.payload.web/init.scope/memory.current": "1048576",
+		"fs/lxc.payload.web/init.scope/memory.stat":    "anon 1048576\ninactive_file 0",
+		"fs/lxc.payload.web/init.scope/cpu.stat":       "usage_usec 1000\nnr_periods 0\nnr_throttled 0\nthrottled_usec 0",
+	})
+	limits, err := readCgroupLimits(procCgroup, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CgroupLimits{Version: 2, MemLimit: 2 << 30, MemUsed: 600 << 20, CPUQuota: 1.5, CPUUsage: 5000000, NrPeriods: 86400, NrThrottled: 1200, ThrottledTime: 35000000}
+	if limits != want {
+		t.Errorf("limits = %+v, want %+v", limits, want)
+	}
+}
+
+func TestReadCgroupLimitsLXCNamespace(t *testing.T) {
+	dir := t.TempDir()
+	procCgroup := filepath.Join(dir, "cgroup")
+	root := filepath.Join(dir, "fs")
+	// LXC 容器内使用 cgroup 命名空间，挂载点即容器 cgroup，agent 作为 systemd 服务位于其子 cgroup 中
+	writeSysfs(t, dir, map[string]string{
+		"cgroup":                         "0::/system.slice/komari-agent.service",
+		"fs/cgroup.controllers":          "cpu memory io",
+		"fs/memory.max":                  "4294967296",
+		"fs/cpu.max":                     "200000 100000",
+		"fs/memory.current":              "1073741824",
+		"fs/memory.stat":                 "anon 805306368\ninactive_file 268435456",
+		"fs/cpu.stat":                    "usage_usec 90000000\nnr_periods 500\nnr_throttled 20\nthrottled_usec 400000",
+		"fs/system.slice/memory.max":     "max",
+		"fs/system.slice/memory.current": "536870912",
+		"fs/system.slice/cpu.stat":       "usage_usec 60000000",
+		"fs/system.slice/komari-agent.service/memory.max":     "max",
+		"fs/system.slice/komari-agent.service/cpu.max":        "max 100000",
+		"fs/system.slice/komari-agent.service/memory.current": "20971520",
+		"fs/system.slice/komari-agent.service/memory.stat":    "anon 20971520\ninactive_file 0",
+		"fs/system.slice/komari-agent.service/cpu.stat":       "usage_usec 300000\nnr_periods 0\nnr_throttled 0\nthrottled_usec 0",
+	})
+	limits, err := readCgroupLimits(procCgroup, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CgroupLimits{Version: 2, MemLimit: 4 << 30, MemUsed: 768 << 20, CPUQuota: 2, CPUUsage: 90000000, NrPeriods: 500, NrThrottled: 20, ThrottledTime: 400000}
+	if limits != want {
+		t.Errorf("limits = %+v, want %+v", limits, want)
+	}
+
+	// 没有任何限制时同样统计整个容器
+	writeSysfs(t, dir, map[string]string{
+		"fs/memory.max": "max",
+		"fs/cpu.max":    "max 100000",
+	})
+	limits, err = readCgroupLimits(procCgroup, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.MemLimit != 0 || limits.CPUQuota != 0 || limits.MemUsed != 768<<20 || limits.CPUUsage != 90000000 {
+		t.Errorf("unlimited = %+v, want the usage of the whole container", limits)
+	}
+}
+
+func TestReadCgroupLimitsV1(t *testing.T) {
+	dir := t.TempDir()
+	procCgroup := filepath.Join(dir, "cgroup")
+	root := filepath.Join(dir, "fs")
+	// 未使用 cgroup 命名空间，/proc/self/cgroup 中的路径在挂载点下不存在
+	writeSysfs(t, dir, map[string]string{
+		"cgroup":                           "12:memory:/docker/4f66ad9a0b2e\n5:cpu,cpuacct:/docker/4f66ad9a0b2e\n1:name=systemd:/docker/4f66ad9a0b2e",
+		"fs/memory/memory.limit_in_bytes":  "536870912",
+		"fs/memory/memory.usage_in_bytes":  "314572800",
+		"fs/memory/memory.stat":            "cache 104857600\ntotal_inactive_file 52428800",
+		"fs/cpu,cpuacct/cpu.cfs_quota_us":  "50000",
+		"fs/cpu,cpuacct/cpu.cfs_period_us": "100000",
+		"fs/cpu,cpuacct/cpuacct.usage":     "7000000000",
+		"fs/cpu,cpuacct/cpu.stat":          "nr_periods 100\nnr_throttled 10\nthrottled_time 2000000000",
+	})
+	limits, err := readCgroupLimits(procCgroup, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CgroupLimits{Version: 1, MemLimit: 512 << 20, MemUsed: 250 << 20, CPUQuota: 0.5, CPUUsage: 7000000, NrPeriods: 100, NrThrottled: 10, ThrottledTime: 2000000}
+	if limits != want {
+		t.Errorf("limits = %+v, want %+v", limits, want)
+	}
+
+	// 不限制时 limit_in_bytes 为接近 int64 上限的值，cfs_quota_us 为 -1
+	writeSysfs(t, dir, map[string]string{
+		"fs/memory/memory.limit_in_bytes": "9223372036854771712",
+		"fs/cpu,cpuacct/cpu.cfs_quota_us": "-1",
+	})
+	limits, err = readCgroupLimits(procCgroup, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.MemLimit != 0 || limits.CPUQuota != 0 {
+		t.Errorf("unlimited = %+v, want zero limits", limits)
+	}
+}
+
+func TestReadCgroupLimitsMissing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cgroup"), []byte("0::/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 没有 cgroup.controllers 也没有 v1 memory 控制器
+	if _, err := readCgroupLimits(filepath.Join(dir, "cgroup"), dir); err == nil {
+		t.Error("expected an error without a usable hierarchy")
+	}
+}
+
+func TestCgroupLimitsEffectiveCores(t *testing.T) {
+	if got := (CgroupLimits{CPUQuota: 1.5}).EffectiveCores(8); got != 1.5 {
+		t.Errorf("quota 1.5 = %v", got)
+	}
+	if got := (CgroupLimits{CPUQuota: 16}).EffectiveCores(8); got != 8 {
+		t.Errorf("quota above host = %v, want 8", got)
+	}
+	if got := (CgroupLimits{}).EffectiveCores(8); got != 8 {
+		t.Errorf("unlimited = %v, want 8", got)
+	}
+}
+
+func TestCgroupCPUTracker(t *testing.T) {
+	var tracker cgroupCPUTracker
+	now := time.Now()
+	if _, ok := tracker.percent(1000000, 2, now); ok {
+		t.Fatal("first sample should only set the baseline")
+	}
+	// 1 秒内使用 1 核，配额 2 核即 50%
+	if got, ok := tracker.percent(2000000, 2, now.Add(time.Second)); !ok || got != 50 {
+		t.Errorf("percent = %v, %v, want 50", got, ok)
+	}
+	// 计数器回退时重新建立基线
+	if _, ok := tracker.percent(10, 2, now.Add(2*time.Second)); ok {
+		t.Error("counter reset should not report usage")
+	}
+}
+
+func TestContainerFromCgroup(t *testing.T) {
+	tests := map[string]string{
+		"12:memory:/docker/4f66ad9a0b2e4f66ad9a0b2e\n":               "docker",
+		"0::/system.slice/docker-4f66ad9a0b2e4f66ad9a0b2e.scope\n":   "docker",
+		"0::/user.slice/user-1000.slice/libpod-4f66ad9a0b2e.scope\n": "podman",
+		"0::/lxc/web\n":    "lxc",
+		"0::/init.scope\n": "",
+		"0::/system.slice/komari-agent.service\n": "",
+	}
+	for data, want := range tests {
+		if got := containerFromCgroup(data); got != want {
+			t.Errorf("containerFromCgroup(%q) = %q, want %q", data, got, want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package monitoring
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// ConnectionsInfo TCP/UDP 连接数及按状态分组的 TCP 连接数
+type ConnectionsInfo struct {
+	TCP int
+	UDP int
+	// TCPStates 键为 ESTABLISHED、TIME_WAIT、LISTEN 等状态名
+	TCPStates map[string]int
+}
+
+func ConnectionsCount() (tcpCount, udpCount int, err error) {
+	info, err := Connections()
+	return info.TCP, info.UDP, err
+}
+
+// Connections 返回 TCP/UDP 连接数及 TCP 连接的状态分布。
+// Linux 下直接读取 /proc/net，不关联进程；失败时退回 gopsutil 的完整枚举。
+func Connections() (ConnectionsInfo, error) {
+	info, err := connectionsFast()
+	if err == nil {
+		return info, nil
+	}
+	fastPathOnce.Do(func() {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			log.Println("Failed to count connections from procfs, falling back to full enumeration:", err)
+		}
+	})
+	return connectionsSlow()
+}
+
+// fastPathOnce 快速路径失败的日志只输出一次
+var fastPathOnce sync.Once
+
+// connectionsSlow 通过 gopsutil 枚举所有套接字并统计
+func connectionsSlow() (ConnectionsInfo, error) {
+	info := ConnectionsInfo{TCPStates: map[string]int{}}
+	tcps, err := net.Connections("tcp")
+	if err != nil {
+		return info, fmt.Errorf("failed to get TCP connections: %w", err)
+	}
+	udps, err := net.Connections("udp")
+	if err != nil {
+		return info, fmt.Errorf("failed to get UDP connections: %w", err)
+	}
+	info.TCP = len(tcps)
+	info.UDP = len(udps)
+	for _, c := range tcps {
+		info.TCPStates[c.Status]++
+	}
+	return info, nil
+}
+
+// ListeningPort 正在监听的端口及其所属进程
+type ListeningPort struct {
+	// Protocol tcp、tcp6、udp 或 udp6
+	Protocol string
+	Address  string
+	Port     uint32
+	PID      int32
+	// Process 进程名，无权限或进程已退出时为空
+	Process string
+}
+
+// ListeningPorts 返回处于 LISTEN 状态的 TCP 套接字与未连接的 UDP 套接字，按协议和端口排序
+func ListeningPorts() ([]ListeningPort, error) {
+	kinds := []struct {
+		kind, protocol string
+		listening      func(net.ConnectionStat) bool
+	}{
+		{"tcp4", "tcp", func(c net.ConnectionStat) bool { return c.Status == "LISTEN" }},
+		{"tcp6", "tcp6", func(c net.ConnectionStat) bool { return c.Status == "LISTEN" }},
+		{"udp4", "udp", func(c net.ConnectionStat) bool { return c.Raddr.Port == 0 }},
+		{"udp6", "udp6", func(c net.ConnectionStat) bool { return c.Raddr.Port == 0 }},
+	}
+	names := map[int32]string{}
+	seen := map[ListeningPort]struct{}{}
+	ports := []ListeningPort{}
+	for _, k := range kinds {
+		conns, err := net.Connections(k.kind)
+		if err != nil {
+			return ports, fmt.Errorf("failed to get %s connections: %w", k.kind, err)
+		}
+		for _, c := range conns {
+			if !k.listening(c) {
+				continue
+			}
+			port := ListeningPort{
+				Protocol: k.protocol,
+				Address:  c.Laddr.IP,
+				Port:     c.Laddr.Port,
+				PID:      c.Pid,
+				Process:  processName(c.Pid, names),
+			}
+			if _, ok := seen[port]; ok {
+				// SO_REUSEPORT 等情况下同一进程可能有多个相同的监听套接字
+				continue
+			}
+			seen[port] = struct{}{}
+			ports = append(ports, port)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Address < ports[j].Address
+	})
+	return ports, nil
+}
+
+// processName 查询进程名并缓存，查询失败时返回空字符串
+func processName(pid int32, cache map[int32]string) string {
+	if pid <= 0 {
+		return ""
+	}
+	if name, ok := cache[pid]; ok {
+		return name
+	}
+	name := ""
+	if p, err := process.NewProcess(pid); err == nil {
+		name, _ = p.Name()
+	}
+	cache[pid] = name
+	return name
+}
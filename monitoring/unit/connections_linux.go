@@ -0,0 +1,114 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procNetTCPStates /proc/net/tcp 中 st 列的取值，名称与 gopsutil 一致
+var procNetTCPStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+	"0C": "SYN_RECV",
+}
+
+// connectionsFast 从 /proc/net 统计连接数，不遍历 /proc/<pid>/fd
+func connectionsFast() (ConnectionsInfo, error) {
+	return connectionsFromProc("/proc")
+}
+
+// connectionsFromProc TCP 逐行读取 tcp/tcp6 以按状态分组，UDP 直接取 sockstat/sockstat6 的 inuse
+func connectionsFromProc(procRoot string) (ConnectionsInfo, error) {
+	info := ConnectionsInfo{TCPStates: map[string]int{}}
+	for _, name := range []string{"tcp", "tcp6"} {
+		if err := countTCPStates(filepath.Join(procRoot, "net", name), info.TCPStates); err != nil {
+			// 内核未启用 IPv6 时没有 tcp6
+			if name == "tcp6" && os.IsNotExist(err) {
+				continue
+			}
+			return info, err
+		}
+	}
+	for _, n := range info.TCPStates {
+		info.TCP += n
+	}
+
+	for _, file := range []struct{ name, key string }{{"sockstat", "UDP"}, {"sockstat6", "UDP6"}} {
+		n, err := sockstatInUse(filepath.Join(procRoot, "net", file.name), file.key)
+		if err != nil {
+			if file.name == "sockstat6" && os.IsNotExist(err) {
+				continue
+			}
+			return info, err
+		}
+		info.UDP += n
+	}
+	return info, nil
+}
+
+// countTCPStates 统计 /proc/net/tcp 格式文件中各状态的套接字数
+func countTCPStates(path string, states map[string]int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024)
+	// 跳过表头
+	scanner.Scan()
+	for scanner.Scan() {
+		// 字段依次为 sl local_address rem_address st ...
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) < 4 {
+			continue
+		}
+		state, ok := procNetTCPStates[string(fields[3])]
+		if !ok {
+			state = "NONE"
+		}
+		states[state]++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// sockstatInUse 读取 sockstat 中 "<key>: inuse N" 的 N
+func sockstatInUse(path, key string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != key+":" || fields[1] != "inuse" {
+			continue
+		}
+		n, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s line in %s: %q", key, path, line)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("no %s line in %s", key, path)
+}
@@ -0,0 +1,67 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const procNetTCPHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+func writeProcNet(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, "net"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, "net", name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConnectionsFromProc(t *testing.T) {
+	root := t.TempDir()
+	writeProcNet(t, root, map[string]string{
+		"tcp": procNetTCPHeader +
+			"   0: 0100007F:BC8F 00000000:0000 0A 00000000:00000000 00:00000000 00000000 65534        0 913 1 0000000000000000 100 0 0 10 0\n" +
+			"   1: 0200000A:0016 0100000A:D431 01 00000000:00000000 02:000A7B2D 00000000     0        0 662 4 0000000000000000 20 4 29 10 -1\n" +
+			"   2: 0200000A:0016 0100000A:D432 06 00000000:00000000 03:00000F5A 00000000     0        0 0 3 0000000000000000\n",
+		"tcp6": procNetTCPHeader +
+			"   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 700 1 0000000000000000 100 0 0 10 0\n" +
+			"   1: 00000000000000000000000001000000:0050 00000000000000000000000001000000:9C40 08 00000000:00000000 00:00000000 00000000     0        0 701 1 0000000000000000 20 4 0 10 -1\n",
+		"sockstat":  "sockets: used 20\nTCP: inuse 6 orphan 0 tw 2 alloc 6 mem 0\nUDP: inuse 3 mem 1\nUDPLITE: inuse 0\n",
+		"sockstat6": "TCP6: inuse 2\nUDP6: inuse 2\nUDPLITE6: inuse 0\n",
+	})
+
+	info, err := connectionsFromProc(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.TCP != 5 || info.UDP != 5 {
+		t.Errorf("TCP = %d, UDP = %d; want 5, 5", info.TCP, info.UDP)
+	}
+	want := map[string]int{"LISTEN": 2, "ESTABLISHED": 1, "TIME_WAIT": 1, "CLOSE_WAIT": 1}
+	for state, n := range want {
+		if info.TCPStates[state] != n {
+			t.Errorf("TCPStates[%s] = %d, want %d (%v)", state, info.TCPStates[state], n, info.TCPStates)
+		}
+	}
+}
+
+func TestConnectionsFromProcWithoutIPv6(t *testing.T) {
+	root := t.TempDir()
+	writeProcNet(t, root, map[string]string{
+		"tcp":      procNetTCPHeader,
+		"sockstat": "TCP: inuse 0 orphan 0 tw 0 alloc 0 mem 0\nUDP: inuse 1 mem 0\n",
+	})
+	info, err := connectionsFromProc(root)
+	if err != nil || info.TCP != 0 || info.UDP != 1 {
+		t.Fatalf("connectionsFromProc = %+v, %v", info, err)
+	}
+
+	// 缺少 /proc/net/tcp 时应返回错误以便退回 gopsutil
+	if _, err := connectionsFromProc(t.TempDir()); err == nil {
+		t.Error("expected an error without /proc/net")
+	}
+}
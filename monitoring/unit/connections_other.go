@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+import "errors"
+
+// connectionsFast 非 Linux 平台没有快速路径
+func connectionsFast() (ConnectionsInfo, error) {
+	return ConnectionsInfo{}, errors.ErrUnsupported
+}
@@ -0,0 +1,288 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContainerInfo 单个容器的状态与资源占用
+type ContainerInfo struct {
+	// ID 容器 ID 的前 12 位
+	ID    string
+	Name  string
+	Image string
+	// State created、running、paused、restarting、exited、dead 等
+	State string
+	// Health healthy、unhealthy、starting，未配置健康检查时为空
+	Health       string
+	RestartCount int
+	// CPU 与上一次采集之间的 CPU 使用率，100 表示占满一个核心
+	CPU float64
+	// MemUsed 不含可回收页缓存的内存占用，与 docker stats 一致
+	MemUsed  uint64
+	MemLimit uint64
+	// NetRx/NetTx 容器启动以来所有网卡的累计流量，单位字节
+	NetRx uint64
+	NetTx uint64
+}
+
+// defaultContainerSockets --container-socket=auto 时依次尝试的路径
+func defaultContainerSockets() []string {
+	sockets := []string{"/var/run/docker.sock", "/run/podman/podman.sock"}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		sockets = append(sockets, filepath.Join(dir, "podman", "podman.sock"), filepath.Join(dir, "docker.sock"))
+	}
+	return sockets
+}
+
+// ContainerSocket 解析 --container-socket，auto 时返回第一个存在的 Docker/Podman 套接字
+func ContainerSocket(setting string) (string, error) {
+	if setting != "auto" {
+		return setting, nil
+	}
+	for _, path := range defaultContainerSockets() {
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no Docker or Podman socket found")
+}
+
+// engineClient 通过 unix 套接字访问 Docker Engine API（Podman 的兼容接口相同）
+type engineClient struct {
+	socket string
+	http   *http.Client
+}
+
+var (
+	engineMu sync.Mutex
+	engine   *engineClient
+)
+
+func getEngineClient(socket string) *engineClient {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+	if engine == nil || engine.socket != socket {
+		engine = newEngineClient(socket)
+	}
+	return engine
+}
+
+func newEngineClient(socket string) *engineClient {
+	return &engineClient{
+		socket: socket,
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+			MaxIdleConns:        containerWorkers,
+			MaxIdleConnsPerHost: containerWorkers,
+			IdleConnTimeout:     time.Minute,
+		}},
+	}
+}
+
+func (c *engineClient) get(ctx context.Context, path string, v interface{}) error {
+	// 主机名不会被使用，请求经由 DialContext 发往套接字
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://engine"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type engineContainer struct {
+	ID    string   `json:"Id"`
+	Names []string `json:"Names"`
+	Image string   `json:"Image"`
+	State string   `json:"State"`
+}
+
+type engineInspect struct {
+	RestartCount int `json:"RestartCount"`
+	State        struct {
+		Health *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+}
+
+type engineStats struct {
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage  uint64   `json:"total_usage"`
+			PercpuUsage []uint64 `json:"percpu_usage"`
+		} `json:"cpu_usage"`
+		SystemCPUUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs     int    `json:"online_cpus"`
+	} `json:"cpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+}
+
+// containerWorkers 同时查询容器详情与资源占用的请求数
+const containerWorkers = 4
+
+// containerCPUSample 容器与整机的累计 CPU 时间（纳秒）
+type containerCPUSample struct {
+	total, system uint64
+}
+
+// containerCPUTracker 保存上一次采集时各容器的 CPU 时间
+type containerCPUTracker struct {
+	mu   sync.Mutex
+	last map[string]containerCPUSample
+}
+
+var containerTracker containerCPUTracker
+
+// Containers 列出 socket 上的全部容器，并查询运行中容器的健康状态与资源占用
+func Containers(ctx context.Context, socket string) ([]ContainerInfo, error) {
+	return listContainers(ctx, getEngineClient(socket), &containerTracker)
+}
+
+func listContainers(ctx context.Context, c *engineClient, tracker *containerCPUTracker) ([]ContainerInfo, error) {
+	var list []engineContainer
+	if err := c.get(ctx, "/containers/json?all=1", &list); err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	containers := make([]ContainerInfo, len(list))
+	samples := make([]*containerCPUSample, len(list))
+	for i, ec := range list {
+		containers[i] = ContainerInfo{ID: shortID(ec.ID), Image: ec.Image, State: ec.State}
+		if len(ec.Names) > 0 {
+			containers[i].Name = strings.TrimPrefix(ec.Names[0], "/")
+		}
+	}
+
+	// 并行查询各容器，超时后不再发起新的请求，未查询到的容器只保留列表中的信息
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(containerWorkers, len(list)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				samples[i] = inspectContainer(ctx, c, tracker, list[i], &containers[i])
+			}
+		}()
+	}
+dispatch:
+	for i := range list {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// 本次未取得数据的运行中容器保留上一次的采样，下次仍能计算 CPU 使用率
+	tracker.mu.Lock()
+	current := make(map[string]containerCPUSample, len(list))
+	for i, ec := range list {
+		if samples[i] != nil {
+			current[ec.ID] = *samples[i]
+		} else if prev, ok := tracker.last[ec.ID]; ok && ec.State == "running" {
+			current[ec.ID] = prev
+		}
+	}
+	tracker.last = current
+	tracker.mu.Unlock()
+	return containers, ctx.Err()
+}
+
+// inspectContainer 查询容器的健康状态与重启次数，运行中的容器还查询资源占用，返回本次的 CPU 采样
+func inspectContainer(ctx context.Context, c *engineClient, tracker *containerCPUTracker, ec engineContainer, info *ContainerInfo) *containerCPUSample {
+	var inspect engineInspect
+	if err := c.get(ctx, "/containers/"+url.PathEscape(ec.ID)+"/json", &inspect); err == nil {
+		info.RestartCount = inspect.RestartCount
+		if inspect.State.Health != nil {
+			info.Health = inspect.State.Health.Status
+		}
+	}
+	if ec.State != "running" {
+		return nil
+	}
+	var stats engineStats
+	if err := c.get(ctx, "/containers/"+url.PathEscape(ec.ID)+"/stats?stream=false&one-shot=true", &stats); err != nil {
+		return nil
+	}
+	info.MemUsed, info.MemLimit = containerMemory(stats)
+	for _, n := range stats.Networks {
+		info.NetRx += n.RxBytes
+		info.NetTx += n.TxBytes
+	}
+	sample := containerCPUSample{total: stats.CPUStats.CPUUsage.TotalUsage, system: stats.CPUStats.SystemCPUUsage}
+	info.CPU = tracker.percent(ec.ID, sample, containerCPUs(stats))
+	return &sample
+}
+
+// percent 按 docker stats 的公式计算：容器 CPU 时间增量 / 整机 CPU 时间增量 × CPU 数 × 100
+func (t *containerCPUTracker) percent(id string, cur containerCPUSample, cpus int) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev, ok := t.last[id]
+	if !ok || cur.total < prev.total || cur.system <= prev.system {
+		return 0
+	}
+	return float64(cur.total-prev.total) / float64(cur.system-prev.system) * float64(cpus) * 100
+}
+
+func containerCPUs(stats engineStats) int {
+	if stats.CPUStats.OnlineCPUs > 0 {
+		return stats.CPUStats.OnlineCPUs
+	}
+	if n := len(stats.CPUStats.CPUUsage.PercpuUsage); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// containerMemory 与 docker stats 一致，从用量中扣除非活跃的页缓存（cgroup v2 为 inactive_file，v1 为 total_inactive_file）
+func containerMemory(stats engineStats) (used, limit uint64) {
+	used = stats.MemoryStats.Usage
+	inactive, ok := stats.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		inactive = stats.MemoryStats.Stats["total_inactive_file"]
+	}
+	if inactive < used {
+		used -= inactive
+	}
+	return used, stats.MemoryStats.Limit
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,182 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// engineStandIn 在 unix 套接字上模拟 Docker Engine API
+func engineStandIn(t *testing.T) (socket string, cpuTotal *atomic.Uint64) {
+	t.Helper()
+	socket = filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	cpuTotal = &atomic.Uint64{}
+	var system atomic.Uint64
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, body string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "1" {
+			t.Errorf("list without all=1: %s", r.URL)
+		}
+		reply(w, `[
+			{"Id":"4f66ad9a0b2e8c7d1f2a3b4c5d6e7f80","Names":["/web"],"Image":"nginx:1.25","State":"running"},
+			{"Id":"9c1e2d3f4a5b6c7d8e9f0a1b2c3d4e5f","Names":["/job"],"Image":"busybox","State":"exited"}
+		]`)
+	})
+	mux.HandleFunc("/containers/4f66ad9a0b2e8c7d1f2a3b4c5d6e7f80/json", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, `{"RestartCount":2,"State":{"Status":"running","Health":{"Status":"healthy"}}}`)
+	})
+	mux.HandleFunc("/containers/9c1e2d3f4a5b6c7d8e9f0a1b2c3d4e5f/json", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, `{"RestartCount":0,"State":{"Status":"exited"}}`)
+	})
+	mux.HandleFunc("/containers/4f66ad9a0b2e8c7d1f2a3b4c5d6e7f80/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "false" {
+			t.Errorf("stats without stream=false: %s", r.URL)
+		}
+		// 每次请求整机 CPU 时间增加 4s（2 核 × 2s）
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"cpu_stats": map[string]interface{}{
+				"cpu_usage":        map[string]interface{}{"total_usage": cpuTotal.Load()},
+				"system_cpu_usage": system.Add(4e9),
+				"online_cpus":      2,
+			},
+			"memory_stats": map[string]interface{}{
+				"usage": 150 << 20,
+				"limit": 512 << 20,
+				"stats": map[string]uint64{"inactive_file": 50 << 20},
+			},
+			"networks": map[string]interface{}{
+				"eth0": map[string]uint64{"rx_bytes": 1000, "tx_bytes": 200},
+				"eth1": map[string]uint64{"rx_bytes": 24, "tx_bytes": 56},
+			},
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+		http.NotFound(w, r)
+	})
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener.Close()
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return socket, cpuTotal
+}
+
+func TestListContainers(t *testing.T) {
+	socket, cpuTotal := engineStandIn(t)
+	client := newEngineClient(socket)
+	tracker := &containerCPUTracker{}
+
+	containers, err := listContainers(context.Background(), client, tracker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 2 {
+		t.Fatalf("containers = %+v", containers)
+	}
+	web := containers[0]
+	want := ContainerInfo{ID: "4f66ad9a0b2e", Name: "web", Image: "nginx:1.25", State: "running", Health: "healthy", RestartCount: 2,
+		MemUsed: 100 << 20, MemLimit: 512 << 20, NetRx: 1024, NetTx: 256}
+	if web != want {
+		t.Errorf("web = %+v, want %+v", web, want)
+	}
+	if job := containers[1]; job.Name != "job" || job.State != "exited" || job.Health != "" || job.MemLimit != 0 {
+		t.Errorf("job = %+v", job)
+	}
+
+	// 两次采集之间容器使用了 1s CPU 时间，整机 4s（2 核）：占 0.5 核
+	cpuTotal.Store(1e9)
+	containers, err = listContainers(context.Background(), client, tracker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if containers[0].CPU != 50 {
+		t.Errorf("cpu = %v, want 50", containers[0].CPU)
+	}
+}
+
+func TestListContainersEngineError(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "missing.sock")
+	_, err := listContainers(context.Background(), newEngineClient(socket), &containerCPUTracker{})
+	if err == nil || !strings.Contains(err.Error(), "failed to list containers") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestListContainersTimeoutKeepsPartialResults(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"Id":"fast1","Names":["/fast1"],"State":"running"},
+			{"Id":"fast2","Names":["/fast2"],"State":"running"},
+			{"Id":"slow1","Names":["/slow1"],"State":"running"},
+			{"Id":"slow2","Names":["/slow2"],"State":"running"}
+		]`))
+	})
+	mux.HandleFunc("/containers/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/containers/slow") && strings.HasSuffix(r.URL.Path, "/stats") {
+			// 卡住的容器直到请求取消才返回
+			<-r.Context().Done()
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/stats") {
+			w.Write([]byte(`{"cpu_stats":{"cpu_usage":{"total_usage":100},"system_cpu_usage":1000},"memory_stats":{"usage":1024,"limit":4096}}`))
+			return
+		}
+		w.Write([]byte(`{"RestartCount":1,"State":{}}`))
+	})
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener.Close()
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	tracker := &containerCPUTracker{last: map[string]containerCPUSample{"slow1": {total: 5, system: 50}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	containers, err := listContainers(ctx, newEngineClient(socket), tracker)
+	if err == nil {
+		t.Error("expected the timeout to be reported")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("listContainers took %v, want it bounded by the context", elapsed)
+	}
+	if len(containers) != 4 {
+		t.Fatalf("containers = %+v, want all four", containers)
+	}
+	for _, c := range containers[:2] {
+		if c.MemUsed != 1024 || c.RestartCount != 1 {
+			t.Errorf("%s = %+v, want its stats", c.Name, c)
+		}
+	}
+	for _, c := range containers[2:] {
+		if c.State != "running" || c.MemUsed != 0 {
+			t.Errorf("%s = %+v, want only the listed state", c.Name, c)
+		}
+	}
+	// 已取得的采样与超时容器上一次的采样都保留
+	if len(tracker.last) != 3 || tracker.last["fast1"].total != 100 || tracker.last["slow1"].total != 5 {
+		t.Errorf("tracker = %+v", tracker.last)
+	}
+}
@@ -2,11 +2,12 @@ package monitoring
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/shirou/gopsutil/v4/cpu"
 )
@@ -52,10 +53,12 @@ func Cpu() CpuInfo {
 
 	cores, err := cpu.Counts(true)
 	if err == nil {
-		cpuinfo.CPUCores = cores
+		// 容器有 CPU 配额时报告配额核数
+		cpuinfo.CPUCores = effectiveCPUCores(cores)
 	}
 
-	percentages, err := cpu.Percent(1*time.Second, false)
+	// 间隔为 0 时不阻塞，返回自上次调用以来的使用率
+	percentages, err := cpu.Percent(0, false)
 	if err == nil && len(percentages) > 0 {
 		cpuinfo.CPUUsage = percentages[0]
 	}
@@ -63,6 +66,165 @@ func Cpu() CpuInfo {
 	return cpuinfo
 }
 
+// cpuTimesTracker 保存上一次采样的 CPU 时间，按名称（cpu-total、cpu0……）区分，
+// 根据两次采样的差值计算使用率
+type cpuTimesTracker struct {
+	mu   sync.Mutex
+	last map[string]cpu.TimesStat
+}
+
+var (
+	usageTracker  cpuTimesTracker
+	detailTracker cpuTimesTracker
+)
+
+// CpuUsage 返回自上次调用以来的总体 CPU 使用率（百分比），不阻塞等待。
+// 首次调用返回开机以来的平均使用率。在容器中运行时为容器占其可用核数的比例。
+func CpuUsage() (float64, error) {
+	times, err := cpu.Times(false)
+	if err != nil {
+		return 0, err
+	}
+	if len(times) == 0 {
+		return 0, fmt.Errorf("no cpu times available")
+	}
+	prev := usageTracker.update(times)
+	if usage, ok := cgroupUsageTracker.cgroupCPUPercent(); ok {
+		return usage, nil
+	}
+	return cpuPercent(prev[0], times[0]), nil
+}
+
+// CpuTimesPercent 两次采样之间各类 CPU 时间的占比，百分比。
+// Linux 下 Guest/GuestNice 已包含在 User/Nice 中。
+type CpuTimesPercent struct {
+	User      float64
+	System    float64
+	Nice      float64
+	Iowait    float64
+	Irq       float64
+	Softirq   float64
+	Steal     float64
+	Guest     float64
+	GuestNice float64
+	Idle      float64
+}
+
+// CpuCoreUsage 单个逻辑核心的使用情况
+type CpuCoreUsage struct {
+	Name  string
+	Usage float64
+	Times CpuTimesPercent
+}
+
+// CpuDetail 总体与每个逻辑核心的 CPU 使用情况
+type CpuDetail struct {
+	Usage float64
+	Times CpuTimesPercent
+	Cores []CpuCoreUsage
+}
+
+// CpuDetailed 返回自上次调用以来的总体及每核 CPU 使用率与时间占比，不阻塞等待。
+// 首次调用返回开机以来的平均值。
+func CpuDetailed() (CpuDetail, error) {
+	var detail CpuDetail
+	total, err := cpu.Times(false)
+	if err != nil {
+		return detail, err
+	}
+	if len(total) == 0 {
+		return detail, fmt.Errorf("no cpu times available")
+	}
+	// 部分平台不支持每核数据，此时仅返回总体数据
+	cores, _ := cpu.Times(true)
+
+	prev := detailTracker.update(append(total, cores...))
+	detail.Usage = cpuPercent(prev[0], total[0])
+	// 在容器中运行时，总体使用率为容器占其可用核数的比例，时间占比与每核数据仍为主机的
+	if usage, ok := cgroupDetailTracker.cgroupCPUPercent(); ok {
+		detail.Usage = usage
+	}
+	detail.Times = cpuTimesPercent(prev[0], total[0])
+	for i, cur := range cores {
+		detail.Cores = append(detail.Cores, CpuCoreUsage{
+			Name:  cur.CPU,
+			Usage: cpuPercent(prev[i+1], cur),
+			Times: cpuTimesPercent(prev[i+1], cur),
+		})
+	}
+	return detail, nil
+}
+
+// update 保存本次采样，返回与 cur 一一对应的上一次采样，没有记录时为零值
+func (t *cpuTimesTracker) update(cur []cpu.TimesStat) []cpu.TimesStat {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev := make([]cpu.TimesStat, len(cur))
+	next := make(map[string]cpu.TimesStat, len(cur))
+	for i, c := range cur {
+		prev[i] = t.last[c.CPU]
+		next[c.CPU] = c
+	}
+	t.last = next
+	return prev
+}
+
+// cpuTimesPercent 计算两次采样之间各类时间的占比，计数器回退时返回零值
+func cpuTimesPercent(prev, cur cpu.TimesStat) CpuTimesPercent {
+	_, prevTotal := cpuBusyAndTotal(prev)
+	_, curTotal := cpuBusyAndTotal(cur)
+	delta := curTotal - prevTotal
+	if delta <= 0 {
+		return CpuTimesPercent{}
+	}
+	percent := func(prev, cur float64) float64 {
+		if cur <= prev {
+			return 0
+		}
+		p := (cur - prev) / delta * 100
+		if p > 100 {
+			return 100
+		}
+		return p
+	}
+	return CpuTimesPercent{
+		User:      percent(prev.User, cur.User),
+		System:    percent(prev.System, cur.System),
+		Nice:      percent(prev.Nice, cur.Nice),
+		Iowait:    percent(prev.Iowait, cur.Iowait),
+		Irq:       percent(prev.Irq, cur.Irq),
+		Softirq:   percent(prev.Softirq, cur.Softirq),
+		Steal:     percent(prev.Steal, cur.Steal),
+		Guest:     percent(prev.Guest, cur.Guest),
+		GuestNice: percent(prev.GuestNice, cur.GuestNice),
+		Idle:      percent(prev.Idle, cur.Idle),
+	}
+}
+
+// cpuPercent 计算两次采样之间的 CPU 使用率，计数器回退时返回 0
+func cpuPercent(prev, cur cpu.TimesStat) float64 {
+	prevBusy, prevTotal := cpuBusyAndTotal(prev)
+	curBusy, curTotal := cpuBusyAndTotal(cur)
+	if curTotal <= prevTotal || curBusy < prevBusy {
+		return 0
+	}
+	percent := (curBusy - prevBusy) / (curTotal - prevTotal) * 100
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// cpuBusyAndTotal 返回忙碌时间与总时间。Linux 下 guest 时间已计入 user，不重复累加。
+func cpuBusyAndTotal(t cpu.TimesStat) (busy, total float64) {
+	total = t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+	if runtime.GOOS != "linux" {
+		total += t.Guest + t.GuestNice
+	}
+	busy = total - t.Idle - t.Iowait
+	return busy, total
+}
+
 // readCPUNameFromLscpu 从 lscpu 命令读取 CPU 名称
 func readCPUNameFromLscpu() (string, error) {
 	cmd := exec.Command("lscpu")
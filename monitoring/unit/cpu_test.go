@@ -0,0 +1,40 @@
+package monitoring
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+func TestCpuTimesPercent(t *testing.T) {
+	prev := cpu.TimesStat{CPU: "cpu0", User: 100, System: 50, Idle: 800, Iowait: 30, Steal: 20}
+	cur := cpu.TimesStat{CPU: "cpu0", User: 130, System: 60, Idle: 840, Iowait: 40, Steal: 30}
+
+	got := cpuTimesPercent(prev, cur)
+	want := CpuTimesPercent{User: 30, System: 10, Idle: 40, Iowait: 10, Steal: 10}
+	if got != want {
+		t.Errorf("cpuTimesPercent = %+v, want %+v", got, want)
+	}
+	if usage := cpuPercent(prev, cur); math.Abs(usage-50) > 1e-9 {
+		t.Errorf("cpuPercent = %v, want 50", usage)
+	}
+	// 计数器回退（例如 CPU 热插拔）时不返回负值
+	if got := cpuTimesPercent(cur, prev); got != (CpuTimesPercent{}) {
+		t.Errorf("cpuTimesPercent after reset = %+v, want zero", got)
+	}
+}
+
+func TestCpuTimesTrackerMatchesByName(t *testing.T) {
+	var tracker cpuTimesTracker
+	tracker.update([]cpu.TimesStat{{CPU: "cpu0", User: 10}, {CPU: "cpu1", User: 20}})
+
+	// 核心顺序变化或有核心下线时按名称匹配上一次采样
+	prev := tracker.update([]cpu.TimesStat{{CPU: "cpu1", User: 25}, {CPU: "cpu2", User: 5}})
+	if prev[0].User != 20 {
+		t.Errorf("cpu1 previous User = %v, want 20", prev[0].User)
+	}
+	if prev[1] != (cpu.TimesStat{}) {
+		t.Errorf("cpu2 has no previous sample, got %+v", prev[1])
+	}
+}
@@ -12,44 +12,94 @@ type DiskInfo struct {
 	Used  uint64 `json:"used"`
 }
 
+// MountpointInfo 单个挂载点的容量与 inode 用量，容量单位字节
+type MountpointInfo struct {
+	Mountpoint  string
+	Device      string
+	Fstype      string
+	Total       uint64
+	Used        uint64
+	Free        uint64
+	InodesTotal uint64
+	InodesUsed  uint64
+	InodesFree  uint64
+}
+
+// Disk 返回所有统计挂载点的容量总和
 func Disk() DiskInfo {
+	return SumMountpoints(Mountpoints())
+}
+
+// SumMountpoints 汇总各挂载点的容量
+func SumMountpoints(mounts []MountpointInfo) DiskInfo {
 	diskinfo := DiskInfo{}
-	usage, err := disk.Partitions(false) // 使用 false 只获取物理分区
-	if err != nil {
-		diskinfo.Total = 0
-		diskinfo.Used = 0
-	} else {
-		// 如果指定了自定义挂载点，只统计指定的挂载点
-		if flags.IncludeMountpoints != "" {
-			includeMounts := strings.Split(flags.IncludeMountpoints, ";")
-			for _, mountpoint := range includeMounts {
-				mountpoint = strings.TrimSpace(mountpoint)
-				if mountpoint != "" {
-					u, err := disk.Usage(mountpoint)
-					if err != nil {
-						continue
-					} else {
-						diskinfo.Total += u.Total
-						diskinfo.Used += u.Used
-					}
-				}
+	for _, m := range mounts {
+		diskinfo.Total += m.Total
+		diskinfo.Used += m.Used
+	}
+	return diskinfo
+}
+
+// Mountpoints 返回参与统计的各挂载点用量。
+// 指定了 --include-mountpoint 时只统计指定的挂载点，否则统计 isPhysicalDisk 接受的分区。
+func Mountpoints() []MountpointInfo {
+	cfg := flags.Current()
+	mounts := []MountpointInfo{}
+	partitions, err := disk.Partitions(false) // 使用 false 只获取物理分区
+	if cfg.IncludeMountpoints != "" {
+		byMountpoint := make(map[string]disk.PartitionStat, len(partitions))
+		for _, part := range partitions {
+			byMountpoint[part.Mountpoint] = part
+		}
+		for _, mountpoint := range strings.Split(cfg.IncludeMountpoints, ";") {
+			mountpoint = strings.TrimSpace(mountpoint)
+			if mountpoint == "" {
+				continue
 			}
-		} else {
-			// 使用默认逻辑，排除临时文件系统和网络驱动器
-			for _, part := range usage {
-				if isPhysicalDisk(part) {
-					u, err := disk.Usage(part.Mountpoint)
-					if err != nil {
-						continue
-					} else {
-						diskinfo.Total += u.Total
-						diskinfo.Used += u.Used
-					}
-				}
+			part, ok := byMountpoint[mountpoint]
+			if !ok {
+				part = disk.PartitionStat{Mountpoint: mountpoint}
+			}
+			if m, err := mountpointUsage(part); err == nil {
+				mounts = append(mounts, m)
 			}
 		}
+		return mounts
 	}
-	return diskinfo
+	if err != nil {
+		return mounts
+	}
+	// 使用默认逻辑，排除临时文件系统和网络驱动器
+	for _, part := range partitions {
+		if isPhysicalDisk(part) {
+			if m, err := mountpointUsage(part); err == nil {
+				mounts = append(mounts, m)
+			}
+		}
+	}
+	return mounts
+}
+
+func mountpointUsage(part disk.PartitionStat) (MountpointInfo, error) {
+	u, err := disk.Usage(part.Mountpoint)
+	if err != nil {
+		return MountpointInfo{}, err
+	}
+	fstype := part.Fstype
+	if fstype == "" {
+		fstype = u.Fstype
+	}
+	return MountpointInfo{
+		Mountpoint:  part.Mountpoint,
+		Device:      part.Device,
+		Fstype:      fstype,
+		Total:       u.Total,
+		Used:        u.Used,
+		Free:        u.Free,
+		InodesTotal: u.InodesTotal,
+		InodesUsed:  u.InodesUsed,
+		InodesFree:  u.InodesFree,
+	}, nil
 }
 
 // isPhysicalDisk 判断分区是否为物理磁盘
@@ -109,9 +159,10 @@ func isPhysicalDisk(part disk.PartitionStat) bool {
 }
 
 func DiskList() ([]string, error) {
+	cfg := flags.Current()
 	diskList := []string{}
-	if flags.IncludeMountpoints != "" {
-		includeMounts := strings.Split(flags.IncludeMountpoints, ";")
+	if cfg.IncludeMountpoints != "" {
+		includeMounts := strings.Split(cfg.IncludeMountpoints, ";")
 		for _, mountpoint := range includeMounts {
 			mountpoint = strings.TrimSpace(mountpoint)
 			if mountpoint != "" {
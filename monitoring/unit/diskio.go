@@ -0,0 +1,183 @@
+package monitoring
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// DiskIOInfo 单个块设备在两次采样之间的平均读写情况
+type DiskIOInfo struct {
+	Name string
+	// ReadSpeed/WriteSpeed 单位字节/秒
+	ReadSpeed  uint64
+	WriteSpeed uint64
+	ReadIOPS   float64
+	WriteIOPS  float64
+	// Await 每个 I/O 请求的平均耗时，单位毫秒
+	Await float64
+	// Util 设备忙碌时间占比，百分比
+	Util float64
+}
+
+// 默认不统计的虚拟块设备前缀
+var diskDevicesToExclude = []string{
+	"loop",
+	"ram",
+	"zram",
+	"sr",
+	"fd",
+}
+
+// diskIOTracker 保存上一次采样的各设备计数器，根据两次采样的差值计算速率
+type diskIOTracker struct {
+	mu   sync.Mutex
+	at   time.Time
+	last map[string]disk.IOCountersStat
+}
+
+var ioTracker diskIOTracker
+
+// DiskIO 返回自上次调用以来各块设备的读写速率、IOPS、平均耗时与利用率，不阻塞等待。
+// 指定了 --include-disk-devices 时只统计指定的设备，否则统计除虚拟设备与分区之外的整盘，
+// 并排除 --exclude-disk-devices 中的设备。首次调用时各项速率为 0。
+func DiskIO() ([]DiskIOInfo, error) {
+	cfg := flags.Current()
+	counters, err := disk.IOCounters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk IO counters: %w", err)
+	}
+	includeDisks := parseNameList(cfg.IncludeDiskDevices)
+	excludeDisks := parseNameList(cfg.ExcludeDiskDevices)
+
+	selected := make(map[string]disk.IOCountersStat, len(counters))
+	for name, stat := range counters {
+		if shouldIncludeDisk(name, counters, includeDisks, excludeDisks) {
+			selected[name] = stat
+		}
+	}
+	return ioTracker.update(selected, time.Now()), nil
+}
+
+// DiskDeviceList 返回参与 I/O 统计的设备名称
+func DiskDeviceList() ([]string, error) {
+	cfg := flags.Current()
+	counters, err := disk.IOCounters()
+	if err != nil {
+		return nil, err
+	}
+	includeDisks := parseNameList(cfg.IncludeDiskDevices)
+	excludeDisks := parseNameList(cfg.ExcludeDiskDevices)
+	devices := []string{}
+	for name := range counters {
+		if shouldIncludeDisk(name, counters, includeDisks, excludeDisks) {
+			devices = append(devices, name)
+		}
+	}
+	sort.Strings(devices)
+	return devices, nil
+}
+
+func shouldIncludeDisk(name string, all map[string]disk.IOCountersStat, includeDisks, excludeDisks map[string]struct{}) bool {
+	// 如果定义了白名单，则只包括白名单中的设备
+	if len(includeDisks) > 0 {
+		_, ok := includeDisks[name]
+		return ok
+	}
+	if _, ok := excludeDisks[name]; ok {
+		return false
+	}
+	for _, prefix := range diskDevicesToExclude {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	// 分区的 I/O 已计入所在磁盘，避免重复统计
+	return !isDiskPartition(sysfsRoot(), name, all)
+}
+
+// isDiskPartition 判断设备是否为分区。Linux 上以 /sys/class/block/<name>/partition 是否存在为准，
+// 读取不到 sysfs 时按名称判断是否为列表中另一设备的分区，例如 sda1、nvme0n1p1、mmcblk0p2
+func isDiskPartition(root, name string, all map[string]disk.IOCountersStat) bool {
+	if runtime.GOOS == "linux" {
+		dev := filepath.Join(root, "class", "block", name)
+		if _, err := os.Stat(dev); err == nil {
+			_, err := os.Stat(filepath.Join(dev, "partition"))
+			return err == nil
+		}
+	}
+	return isPartitionName(name, all)
+}
+
+// isPartitionName 按名称判断设备是否为列表中另一设备的分区。
+// 无法区分 dm-10、md10 这类以数字结尾的整盘，仅在没有 sysfs 时使用。
+func isPartitionName(name string, all map[string]disk.IOCountersStat) bool {
+	for parent := range all {
+		if parent == name || !strings.HasPrefix(name, parent) {
+			continue
+		}
+		suffix := strings.TrimPrefix(strings.TrimPrefix(name, parent), "p")
+		if suffix != "" && strings.Trim(suffix, "0123456789") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// update 记录本次采样，返回按名称排序的各设备速率。新出现的设备或计数器回退时速率为 0。
+func (t *diskIOTracker) update(cur map[string]disk.IOCountersStat, now time.Time) []DiskIOInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	elapsed := now.Sub(t.at)
+	if t.at.IsZero() {
+		elapsed = 0
+	}
+	result := make([]DiskIOInfo, 0, len(cur))
+	for name, stat := range cur {
+		prev, ok := t.last[name]
+		if !ok {
+			prev = stat
+		}
+		info := diskIORates(prev, stat, elapsed)
+		info.Name = name
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	t.at, t.last = now, cur
+	return result
+}
+
+// diskIORates 计算两次采样之间的平均读写速率、IOPS、平均耗时与利用率
+func diskIORates(prev, cur disk.IOCountersStat, elapsed time.Duration) DiskIOInfo {
+	var info DiskIOInfo
+	seconds := elapsed.Seconds()
+	if seconds <= 0 ||
+		cur.ReadBytes < prev.ReadBytes || cur.WriteBytes < prev.WriteBytes ||
+		cur.ReadCount < prev.ReadCount || cur.WriteCount < prev.WriteCount ||
+		cur.ReadTime < prev.ReadTime || cur.WriteTime < prev.WriteTime || cur.IoTime < prev.IoTime {
+		return info
+	}
+	reads := cur.ReadCount - prev.ReadCount
+	writes := cur.WriteCount - prev.WriteCount
+	info.ReadSpeed = uint64(float64(cur.ReadBytes-prev.ReadBytes) / seconds)
+	info.WriteSpeed = uint64(float64(cur.WriteBytes-prev.WriteBytes) / seconds)
+	info.ReadIOPS = float64(reads) / seconds
+	info.WriteIOPS = float64(writes) / seconds
+	if reads+writes > 0 {
+		info.Await = float64(cur.ReadTime-prev.ReadTime+cur.WriteTime-prev.WriteTime) / float64(reads+writes)
+	}
+	// IoTime 单位为毫秒
+	info.Util = float64(cur.IoTime-prev.IoTime) / (seconds * 1000) * 100
+	if info.Util > 100 {
+		info.Util = 100
+	}
+	return info
+}
@@ -0,0 +1,92 @@
+package monitoring
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+func TestDiskIORates(t *testing.T) {
+	prev := disk.IOCountersStat{ReadCount: 100, WriteCount: 50, ReadBytes: 1 << 20, WriteBytes: 2 << 20, ReadTime: 400, WriteTime: 600, IoTime: 1000}
+	cur := disk.IOCountersStat{ReadCount: 300, WriteCount: 250, ReadBytes: 5 << 20, WriteBytes: 10 << 20, ReadTime: 1200, WriteTime: 1800, IoTime: 2000}
+
+	got := diskIORates(prev, cur, 2*time.Second)
+	if got.ReadSpeed != 2<<20 || got.WriteSpeed != 4<<20 {
+		t.Errorf("speed = %d/%d, want %d/%d", got.ReadSpeed, got.WriteSpeed, 2<<20, 4<<20)
+	}
+	if got.ReadIOPS != 100 || got.WriteIOPS != 100 {
+		t.Errorf("iops = %v/%v, want 100/100", got.ReadIOPS, got.WriteIOPS)
+	}
+	// (800+1200)ms / 400 次请求
+	if math.Abs(got.Await-5) > 1e-9 {
+		t.Errorf("await = %v, want 5", got.Await)
+	}
+	if math.Abs(got.Util-50) > 1e-9 {
+		t.Errorf("util = %v, want 50", got.Util)
+	}
+	if got := diskIORates(cur, prev, 2*time.Second); got != (DiskIOInfo{}) {
+		t.Errorf("counter reset should yield zero rates, got %+v", got)
+	}
+}
+
+func TestShouldIncludeDisk(t *testing.T) {
+	// 没有 sysfs 时按名称判断
+	old := flags.Current().SysfsRoot
+	flags.Update(func(c *flags.Config) { c.SysfsRoot = t.TempDir() })
+	defer flags.Update(func(c *flags.Config) { c.SysfsRoot = old })
+
+	all := map[string]disk.IOCountersStat{
+		"sda": {}, "sda1": {}, "nvme0n1": {}, "nvme0n1p1": {}, "loop0": {}, "dm-0": {}, "mmcblk0": {}, "mmcblk0p2": {},
+	}
+	var got []string
+	for _, name := range []string{"dm-0", "loop0", "mmcblk0", "mmcblk0p2", "nvme0n1", "nvme0n1p1", "sda", "sda1"} {
+		if shouldIncludeDisk(name, all, nil, parseNameList("dm-0")) {
+			got = append(got, name)
+		}
+	}
+	if want := []string{"mmcblk0", "nvme0n1", "sda"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("included %v, want %v", got, want)
+	}
+	// 白名单可以选择分区
+	if !shouldIncludeDisk("sda1", all, parseNameList("sda1"), nil) || shouldIncludeDisk("sda", all, parseNameList("sda1"), nil) {
+		t.Error("include list not honoured")
+	}
+}
+
+func TestIsDiskPartitionSysfs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("sysfs is Linux only")
+	}
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"class/block/dm-1/dev":            "253:1",
+		"class/block/dm-10/dev":           "253:10",
+		"class/block/md1/dev":             "9:1",
+		"class/block/md10/dev":            "9:10",
+		"class/block/nbd1/dev":            "43:32",
+		"class/block/nbd10/dev":           "43:320",
+		"class/block/nvme0n1/dev":         "259:0",
+		"class/block/nvme0n10/dev":        "259:9",
+		"class/block/nvme0n1p1/dev":       "259:1",
+		"class/block/nvme0n1p1/partition": "1",
+		"class/block/sda1/partition":      "1",
+	})
+	all := map[string]disk.IOCountersStat{}
+	for _, name := range []string{"dm-1", "dm-10", "md1", "md10", "nbd1", "nbd10", "nvme0n1", "nvme0n10", "nvme0n1p1", "sda", "sda1"} {
+		all[name] = disk.IOCountersStat{}
+	}
+	var partitions []string
+	for _, name := range []string{"dm-1", "dm-10", "md1", "md10", "nbd1", "nbd10", "nvme0n1", "nvme0n10", "nvme0n1p1", "sda", "sda1"} {
+		if isDiskPartition(root, name, all) {
+			partitions = append(partitions, name)
+		}
+	}
+	if want := []string{"nvme0n1p1", "sda1"}; !reflect.DeepEqual(partitions, want) {
+		t.Errorf("partitions = %v, want %v", partitions, want)
+	}
+}
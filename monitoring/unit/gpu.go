@@ -1 +1,372 @@
 package monitoring
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GpuInfo 单个 GPU 的使用情况。驱动工具不提供的项为 0。
+type GpuInfo struct {
+	Index int
+	Name  string
+	// Vendor nvidia 或 amd
+	Vendor string
+	UUID   string
+	// Utilization GPU 使用率，百分比
+	Utilization float64
+	// MemUsed/MemTotal 显存，单位字节
+	MemUsed  uint64
+	MemTotal uint64
+	// Temperature 核心温度，单位摄氏度
+	Temperature float64
+	// PowerDraw 功耗，单位瓦
+	PowerDraw float64
+	Processes []GpuProcess
+}
+
+// GpuProcess 使用 GPU 的进程
+type GpuProcess struct {
+	PID  int32
+	Name string
+	// MemUsed 占用的显存，单位字节
+	MemUsed uint64
+}
+
+const (
+	nvidiaQueryGPU  = "index,uuid,name,utilization.gpu,memory.used,memory.total,temperature.gpu,power.draw"
+	nvidiaQueryApps = "gpu_uuid,pid,process_name,used_memory"
+)
+
+// gpuToolsTTL 查找 nvidia-smi、rocm-smi 的结果缓存时长。采集器每次上报都会检查是否启用，
+// 不必每秒遍历 PATH；之后安装的驱动工具最迟在这段时间后被发现。
+const gpuToolsTTL = time.Minute
+
+var (
+	gpuToolsMu sync.Mutex
+	gpuToolsAt time.Time
+	nvidiaSmi  string
+	rocmSmi    string
+)
+
+// GpuTools 返回 PATH 中可用的 nvidia-smi、rocm-smi
+func GpuTools() (nvidia, rocm string) {
+	gpuToolsMu.Lock()
+	defer gpuToolsMu.Unlock()
+	if now := time.Now(); gpuToolsAt.IsZero() || now.Sub(gpuToolsAt) >= gpuToolsTTL {
+		nvidiaSmi, _ = exec.LookPath("nvidia-smi")
+		rocmSmi, _ = exec.LookPath("rocm-smi")
+		gpuToolsAt = now
+	}
+	return nvidiaSmi, rocmSmi
+}
+
+// Gpus 通过 nvidia-smi 与 rocm-smi 读取各 GPU 的使用情况，两者都不可用时返回 errors.ErrUnsupported。
+// 部分工具失败时返回其余 GPU 及错误。
+func Gpus(ctx context.Context) ([]GpuInfo, error) {
+	nvidia, rocm := GpuTools()
+	if nvidia == "" && rocm == "" {
+		return nil, errors.ErrUnsupported
+	}
+	var gpus []GpuInfo
+	var errs []error
+	if nvidia != "" {
+		list, err := nvidiaGpus(ctx, nvidia)
+		gpus = append(gpus, list...)
+		errs = append(errs, err)
+	}
+	if rocm != "" {
+		list, err := rocmGpus(ctx, rocm)
+		gpus = append(gpus, list...)
+		errs = append(errs, err)
+	}
+	return gpus, errors.Join(errs...)
+}
+
+// smiGpuNames 返回 nvidia-smi、rocm-smi 报告的 GPU 型号，以逗号分隔，不可用时为空。
+// 只查询型号，不运行完整的指标查询。
+func smiGpuNames() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	nvidia, rocm := GpuTools()
+	var names []string
+	if nvidia != "" {
+		if out, err := exec.CommandContext(ctx, nvidia, "--query-gpu=name", "--format=csv,noheader").Output(); err == nil {
+			names = append(names, parseSmiNames(out)...)
+		}
+	}
+	if rocm != "" {
+		if out, err := exec.CommandContext(ctx, rocm, "--showproductname", "--json").Output(); err == nil {
+			gpus, _ := parseRocmSmi(out)
+			for _, gpu := range gpus {
+				if gpu.Name != "" {
+					names = append(names, gpu.Name)
+				}
+			}
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseSmiNames 解析 nvidia-smi --query-gpu=name 每行一个的型号
+func parseSmiNames(data []byte) []string {
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names
+}
+
+func nvidiaGpus(ctx context.Context, path string) ([]GpuInfo, error) {
+	out, err := exec.CommandContext(ctx, path, "--query-gpu="+nvidiaQueryGPU, "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi: %w", err)
+	}
+	gpus, err := parseNvidiaSmi(out)
+	if err != nil {
+		return nil, err
+	}
+	// 没有计算进程时输出为空，查询失败不影响 GPU 数据
+	if out, err := exec.CommandContext(ctx, path, "--query-compute-apps="+nvidiaQueryApps, "--format=csv,noheader,nounits").Output(); err == nil {
+		if err := parseNvidiaApps(out, gpus); err != nil {
+			return gpus, err
+		}
+	}
+	return gpus, nil
+}
+
+// parseNvidiaSmi 解析 --query-gpu 的 CSV 输出（noheader,nounits），字段顺序与 nvidiaQueryGPU 一致。
+// 不支持的项输出为 [N/A] 或 [Not Supported]，记为 0。
+func parseNvidiaSmi(data []byte) ([]GpuInfo, error) {
+	records, err := readCSV(data)
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi: %w", err)
+	}
+	var gpus []GpuInfo
+	for _, r := range records {
+		if len(r) != 8 {
+			return gpus, fmt.Errorf("nvidia-smi: unexpected record %q", r)
+		}
+		index, err := strconv.Atoi(r[0])
+		if err != nil {
+			return gpus, fmt.Errorf("nvidia-smi: invalid index %q", r[0])
+		}
+		gpus = append(gpus, GpuInfo{
+			Index:       index,
+			UUID:        r[1],
+			Name:        r[2],
+			Vendor:      "nvidia",
+			Utilization: parseSmiFloat(r[3]),
+			// 显存单位为 MiB
+			MemUsed:     uint64(parseSmiFloat(r[4])) << 20,
+			MemTotal:    uint64(parseSmiFloat(r[5])) << 20,
+			Temperature: parseSmiFloat(r[6]),
+			PowerDraw:   parseSmiFloat(r[7]),
+		})
+	}
+	return gpus, nil
+}
+
+// parseNvidiaApps 解析 --query-compute-apps 的 CSV 输出，按 UUID 将进程加入对应的 GPU
+func parseNvidiaApps(data []byte, gpus []GpuInfo) error {
+	records, err := readCSV(data)
+	if err != nil {
+		return fmt.Errorf("nvidia-smi: %w", err)
+	}
+	for _, r := range records {
+		if len(r) != 4 {
+			return fmt.Errorf("nvidia-smi: unexpected record %q", r)
+		}
+		pid, err := strconv.ParseInt(r[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		for i := range gpus {
+			if gpus[i].UUID == r[0] {
+				gpus[i].Processes = append(gpus[i].Processes, GpuProcess{
+					PID:     int32(pid),
+					Name:    r[2],
+					MemUsed: uint64(parseSmiFloat(r[3])) << 20,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimSpace(string(data))))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		for i := range r {
+			r[i] = strings.TrimSpace(r[i])
+		}
+	}
+	return records, nil
+}
+
+// parseSmiFloat 解析数值，[N/A]、[Not Supported] 等返回 0
+func parseSmiFloat(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func rocmGpus(ctx context.Context, path string) ([]GpuInfo, error) {
+	out, err := exec.CommandContext(ctx, path, "--showproductname", "--showuse", "--showmeminfo", "vram", "--showtemp", "--showpower", "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("rocm-smi: %w", err)
+	}
+	gpus, err := parseRocmSmi(out)
+	if err != nil {
+		return nil, err
+	}
+	// 进程列表与进程所在的 GPU 分两次查询，查询失败不影响 GPU 数据
+	if out, err := exec.CommandContext(ctx, path, "--showpids", "--json").Output(); err == nil {
+		var pidGpus map[int32][]int
+		if out, err := exec.CommandContext(ctx, path, "--showpidgpus", "--json").Output(); err == nil {
+			pidGpus, _ = parseRocmPidGpus(out)
+		}
+		if err := parseRocmPids(out, pidGpus, gpus); err != nil {
+			return gpus, err
+		}
+	}
+	return gpus, nil
+}
+
+var rocmCardPattern = regexp.MustCompile(`^card(\d+)$`)
+
+// parseRocmSmi 解析 rocm-smi --json 的输出。各版本的字段名略有不同，按顺序取第一个存在的字段。
+func parseRocmSmi(data []byte) ([]GpuInfo, error) {
+	var cards map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &cards); err != nil {
+		return nil, fmt.Errorf("rocm-smi: %w", err)
+	}
+	var gpus []GpuInfo
+	for card, values := range cards {
+		m := rocmCardPattern.FindStringSubmatch(card)
+		if m == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(m[1])
+		field := func(keys ...string) string {
+			for _, key := range keys {
+				if v, ok := values[key]; ok {
+					return fmt.Sprint(v)
+				}
+			}
+			return ""
+		}
+		gpus = append(gpus, GpuInfo{
+			Index:       index,
+			Name:        field("Card Series", "Card series", "Card Model", "Card model"),
+			Vendor:      "amd",
+			UUID:        field("Unique ID"),
+			Utilization: parseSmiFloat(field("GPU use (%)")),
+			MemUsed:     uint64(parseSmiFloat(field("VRAM Total Used Memory (B)"))),
+			MemTotal:    uint64(parseSmiFloat(field("VRAM Total Memory (B)"))),
+			Temperature: parseSmiFloat(field("Temperature (Sensor edge) (C)", "Temperature (Sensor junction) (C)")),
+			PowerDraw:   parseSmiFloat(field("Average Graphics Package Power (W)", "Current Socket Graphics Package Power (W)")),
+		})
+	}
+	sort.Slice(gpus, func(i, j int) bool { return gpus[i].Index < gpus[j].Index })
+	return gpus, nil
+}
+
+// parseRocmPids 解析 rocm-smi --showpids --json 的输出，值为 "名称, GPU 数, 显存字节数, SDMA, CU 占用"。
+// 进程按 pidGpus（--showpidgpus）加入所在的 GPU；没有对应关系时仅在只有一个 GPU 时记录。
+// 显存为进程在所有 GPU 上的合计，只在进程使用一个 GPU 时记录。
+func parseRocmPids(data []byte, pidGpus map[int32][]int, gpus []GpuInfo) error {
+	var out map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return fmt.Errorf("rocm-smi: %w", err)
+	}
+	if pidGpus == nil && len(gpus) != 1 {
+		return nil
+	}
+	var procs []GpuProcess
+	for key, value := range out["system"] {
+		pid, err := strconv.ParseInt(strings.TrimPrefix(key, "PID"), 10, 32)
+		if err != nil {
+			continue
+		}
+		fields := strings.Split(fmt.Sprint(value), ",")
+		proc := GpuProcess{PID: int32(pid), Name: strings.TrimSpace(fields[0])}
+		if len(fields) >= 3 {
+			proc.MemUsed = uint64(parseSmiFloat(fields[2]))
+		}
+		procs = append(procs, proc)
+	}
+	sort.Slice(procs, func(i, j int) bool { return procs[i].PID < procs[j].PID })
+	if pidGpus == nil {
+		gpus[0].Processes = procs
+		return nil
+	}
+	for _, proc := range procs {
+		indices, ok := pidGpus[proc.PID]
+		if !ok && len(gpus) == 1 {
+			indices = []int{gpus[0].Index}
+		}
+		if len(indices) > 1 {
+			proc.MemUsed = 0
+		}
+		for _, index := range indices {
+			for i := range gpus {
+				if gpus[i].Index == index {
+					gpus[i].Processes = append(gpus[i].Processes, proc)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	rocmPidPattern    = regexp.MustCompile(`PID\s*(\d+)`)
+	rocmNumberPattern = regexp.MustCompile(`\d+`)
+)
+
+// parseRocmPidGpus 解析 rocm-smi --showpidgpus --json 的输出，
+// 键为 "PID 61204 is using 2 DRM device(s)"，值为设备序号列表，如 "[0, 1]"
+func parseRocmPidGpus(data []byte) (map[int32][]int, error) {
+	var out map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("rocm-smi: %w", err)
+	}
+	pidGpus := map[int32][]int{}
+	for key, value := range out["system"] {
+		m := rocmPidPattern.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		pid, err := strconv.ParseInt(m[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		var indices []int
+		for _, n := range rocmNumberPattern.FindAllString(fmt.Sprint(value), -1) {
+			index, _ := strconv.Atoi(n)
+			indices = append(indices, index)
+		}
+		pidGpus[int32(pid)] = indices
+	}
+	return pidGpus, nil
+}
@@ -9,6 +9,10 @@ import (
 )
 
 func GpuName() string {
+	// 优先使用驱动工具报告的型号，可列出全部 GPU
+	if names := smiGpuNames(); names != "" {
+		return names
+	}
 	accept := []string{"vga", "nvidia", "amd", "radeon", "render"}
 	out, err := exec.Command("lspci").Output()
 	if err == nil {
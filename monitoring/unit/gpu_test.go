@@ -1,7 +1,11 @@
 package monitoring
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestGpuName(t *testing.T) {
@@ -11,3 +15,158 @@ func TestGpuName(t *testing.T) {
 	}
 	t.Logf("GPU name: %s", name)
 }
+
+func TestGpuToolsCached(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tool is a shell script")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	defer func() { gpuToolsAt = time.Time{} }()
+	gpuToolsAt = time.Time{}
+
+	if nvidia, rocm := GpuTools(); nvidia != "" || rocm != "" {
+		t.Fatalf("GpuTools() = %q, %q with an empty PATH", nvidia, rocm)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nvidia-smi"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 缓存有效期内不重新查找
+	if nvidia, _ := GpuTools(); nvidia != "" {
+		t.Errorf("GpuTools() looked up PATH again within the TTL: %q", nvidia)
+	}
+	gpuToolsAt = time.Now().Add(-gpuToolsTTL)
+	if nvidia, _ := GpuTools(); nvidia != filepath.Join(dir, "nvidia-smi") {
+		t.Errorf("GpuTools() = %q after the TTL, want the new tool", nvidia)
+	}
+}
+
+func TestParseSmiNames(t *testing.T) {
+	names := parseSmiNames([]byte("NVIDIA GeForce RTX 4090\nNVIDIA A100-SXM4-80GB\n\n"))
+	if len(names) != 2 || names[0] != "NVIDIA GeForce RTX 4090" || names[1] != "NVIDIA A100-SXM4-80GB" {
+		t.Errorf("names = %q", names)
+	}
+	// rocm-smi --showproductname 只含型号字段
+	gpus, err := parseRocmSmi([]byte(`{"card0": {"Card Series": "Navi 31 [Radeon RX 7900 XTX]", "Card Vendor": "Advanced Micro Devices, Inc. [AMD/ATI]"}}`))
+	if err != nil || len(gpus) != 1 || gpus[0].Name != "Navi 31 [Radeon RX 7900 XTX]" {
+		t.Errorf("rocm product names = %+v, %v", gpus, err)
+	}
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseNvidiaSmi(t *testing.T) {
+	gpus, err := parseNvidiaSmi(readTestdata(t, "nvidia-smi-query-gpu.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gpus) != 3 {
+		t.Fatalf("got %d gpus, want 3", len(gpus))
+	}
+	want := GpuInfo{
+		Index:       0,
+		Name:        "NVIDIA GeForce RTX 4090",
+		Vendor:      "nvidia",
+		UUID:        "GPU-5c1e0f5a-9d7b-2f4e-8a51-3b0c7e6d9f12",
+		Utilization: 87,
+		MemUsed:     20480 << 20,
+		MemTotal:    24564 << 20,
+		Temperature: 71,
+		PowerDraw:   386.45,
+	}
+	if got := gpus[0]; got.Name != want.Name || got.UUID != want.UUID || got.Utilization != want.Utilization ||
+		got.MemUsed != want.MemUsed || got.MemTotal != want.MemTotal || got.Temperature != want.Temperature || got.PowerDraw != want.PowerDraw {
+		t.Errorf("gpu 0 = %+v, want %+v", got, want)
+	}
+	// 旧卡不支持的项为 [N/A]、[Not Supported]
+	if got := gpus[2]; got.Index != 2 || got.Utilization != 0 || got.PowerDraw != 0 || got.MemTotal != 11441<<20 {
+		t.Errorf("gpu 2 = %+v", got)
+	}
+
+	if err := parseNvidiaApps(readTestdata(t, "nvidia-smi-query-compute-apps.csv"), gpus); err != nil {
+		t.Fatal(err)
+	}
+	if procs := gpus[0].Processes; len(procs) != 2 || procs[0] != (GpuProcess{PID: 40213, Name: "/usr/bin/python3", MemUsed: 18944 << 20}) || procs[1].Name != "ollama" {
+		t.Errorf("gpu 0 processes = %+v", procs)
+	}
+	if procs := gpus[1].Processes; len(procs) != 1 || procs[0] != (GpuProcess{PID: 51002, Name: "vllm"}) {
+		t.Errorf("gpu 1 processes = %+v", procs)
+	}
+	if len(gpus[2].Processes) != 0 {
+		t.Errorf("gpu 2 processes = %+v, want none", gpus[2].Processes)
+	}
+
+	if _, err := parseNvidiaSmi([]byte("0, GPU-x, name\n")); err == nil {
+		t.Error("expected an error for a short record")
+	}
+	if gpus, err := parseNvidiaSmi(nil); err != nil || len(gpus) != 0 {
+		t.Errorf("empty output = %v, %v", gpus, err)
+	}
+}
+
+func TestParseRocmSmi(t *testing.T) {
+	gpus, err := parseRocmSmi(readTestdata(t, "rocm-smi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gpus) != 2 {
+		t.Fatalf("got %d gpus, want 2", len(gpus))
+	}
+	want := GpuInfo{
+		Index:       0,
+		Name:        "Navi 31 [Radeon RX 7900 XTX]",
+		Vendor:      "amd",
+		Utilization: 94,
+		MemUsed:     16 << 30,
+		MemTotal:    25753026560,
+		Temperature: 52,
+		PowerDraw:   212,
+	}
+	if got := gpus[0]; got.Name != want.Name || got.Vendor != want.Vendor || got.Utilization != want.Utilization ||
+		got.MemUsed != want.MemUsed || got.MemTotal != want.MemTotal || got.Temperature != want.Temperature || got.PowerDraw != want.PowerDraw {
+		t.Errorf("card0 = %+v, want %+v", got, want)
+	}
+	// MI300 等较新的卡没有 edge 传感器，功耗字段名也不同
+	if got := gpus[1]; got.Index != 1 || got.Name != "AMD Instinct MI300X" || got.Temperature != 41 || got.PowerDraw != 96 {
+		t.Errorf("card1 = %+v", got)
+	}
+
+	// 没有 --showpidgpus 的结果时，多个 GPU 无法确定进程所在的 GPU
+	pids := readTestdata(t, "rocm-smi-showpids.json")
+	if err := parseRocmPids(pids, nil, gpus); err != nil || len(gpus[0].Processes) != 0 {
+		t.Errorf("multi-gpu processes = %+v, %v", gpus[0].Processes, err)
+	}
+	single := append([]GpuInfo(nil), gpus[:1]...)
+	if err := parseRocmPids(pids, nil, single); err != nil {
+		t.Fatal(err)
+	}
+	if procs := single[0].Processes; len(procs) != 2 || procs[0] != (GpuProcess{PID: 61204, Name: "python3", MemUsed: 16106127360}) || procs[1].Name != "llama-server" {
+		t.Errorf("processes = %+v", procs)
+	}
+
+	// 按 --showpidgpus 分配到各 GPU，使用多个 GPU 的进程无法区分各 GPU 上的显存
+	pidGpus, err := parseRocmPidGpus(readTestdata(t, "rocm-smi-showpidgpus.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parseRocmPids(pids, pidGpus, gpus); err != nil {
+		t.Fatal(err)
+	}
+	if procs := gpus[0].Processes; len(procs) != 1 || procs[0] != (GpuProcess{PID: 61204, Name: "python3"}) {
+		t.Errorf("card0 processes = %+v", procs)
+	}
+	if procs := gpus[1].Processes; len(procs) != 2 || procs[0].PID != 61204 || procs[1] != (GpuProcess{PID: 61388, Name: "llama-server", MemUsed: 536870912}) {
+		t.Errorf("card1 processes = %+v", procs)
+	}
+
+	if _, err := parseRocmSmi([]byte("WARNING: no AMD GPUs")); err == nil {
+		t.Error("expected an error for non-JSON output")
+	}
+}
@@ -10,24 +10,71 @@ type RamInfo struct {
 	Used  uint64 `json:"used"`
 }
 
+// Ram 返回内存总量与已用。在容器中运行时为 cgroup 的内存上限与用量。
 func Ram() RamInfo {
-	raminfo := RamInfo{}
 	v, err := mem.VirtualMemory()
 	if err != nil {
-		raminfo.Total = 0
-		raminfo.Used = 0
-		return raminfo
+		return RamInfo{}
 	}
-	if flags.MemoryModeAvailable {
-		raminfo.Total = v.Total
-		raminfo.Used = v.Total - v.Available
-		return raminfo
+	return applyMemoryLimit(ramInfo(v))
+}
+
+// ramInfo 按 --memory-mode-available 计算已用内存
+func ramInfo(v *mem.VirtualMemoryStat) RamInfo {
+	if flags.Current().MemoryModeAvailable {
+		return RamInfo{Total: v.Total, Used: v.Total - v.Available}
 	}
-	raminfo.Total = v.Total
-	raminfo.Used = v.Used
+	return RamInfo{Total: v.Total, Used: v.Used}
+}
+
+// MemoryDetail 内存构成明细，除大页数量外单位字节。平台不提供的项为 0。
+type MemoryDetail struct {
+	Available       uint64
+	Free            uint64
+	Buffers         uint64
+	Cached          uint64
+	Shared          uint64
+	Slab            uint64
+	SlabReclaimable uint64
+	Dirty           uint64
+	CommittedAS     uint64
+	CommitLimit     uint64
+	// HugePagesTotal/HugePagesFree 大页数量，单位页
+	HugePagesTotal uint64
+	HugePagesFree  uint64
+	HugePageSize   uint64
+	// Zram 各 zram 设备的压缩情况
+	Zram []ZramInfo
+	// Zswap 未启用 zswap 时为 nil
+	Zswap *ZswapInfo
+}
 
-	return raminfo
+// RamDetail 返回与 Ram() 相同的总量/已用，以及内存构成明细
+func RamDetail() (RamInfo, MemoryDetail, error) {
+	v, err := mem.VirtualMemory()
+	if err != nil {
+		return RamInfo{}, MemoryDetail{}, err
+	}
+	detail := MemoryDetail{
+		Available:       v.Available,
+		Free:            v.Free,
+		Buffers:         v.Buffers,
+		Cached:          v.Cached,
+		Shared:          v.Shared,
+		Slab:            v.Slab,
+		SlabReclaimable: v.Sreclaimable,
+		Dirty:           v.Dirty,
+		CommittedAS:     v.CommittedAS,
+		CommitLimit:     v.CommitLimit,
+		HugePagesTotal:  v.HugePagesTotal,
+		HugePagesFree:   v.HugePagesFree,
+		HugePageSize:    v.HugePageSize,
+		Zram:            readZram(sysfsRoot()),
+		Zswap:           readZswap("/proc/meminfo", sysfsRoot()),
+	}
+	return applyMemoryLimit(ramInfo(v)), detail, nil
 }
+
 func Swap() RamInfo {
 	swapinfo := RamInfo{}
 	s, err := mem.SwapMemory()
@@ -2,28 +2,19 @@ package monitoring
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	stdnet "net"
 	"os/exec"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
 	"github.com/shirou/gopsutil/v4/net"
 )
 
-func ConnectionsCount() (tcpCount, udpCount int, err error) {
-	tcps, err := net.Connections("tcp")
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get TCP connections: %w", err)
-	}
-	udps, err := net.Connections("udp")
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get UDP connections: %w", err)
-	}
-
-	return len(tcps), len(udps), nil
-}
-
 var (
 	// 预定义常见的回环和虚拟接口名称
 	loopbackNames = map[string]struct{}{
@@ -126,6 +117,7 @@ type VnstatOutput struct {
 	Interfaces    []VnstatInterface `json:"interfaces"`
 }
 
+// getVnstatData 读取 vnstat 的统计数据，仅用于首次创建流量账本时导入本周期已有的流量
 func getVnstatData() (map[string]VnstatInterface, error) {
 	cmd := exec.Command("vnstat", "--json")
 	output, err := cmd.Output()
@@ -186,117 +178,192 @@ func calculateMonthlyUsage(iface VnstatInterface, monthRotateDay int) (rx, tx ui
 	return rx, tx
 }
 
-// setVnstatMonthRotate 设置vnstat的月重置日期
-func setVnstatMonthRotate(day int) error {
-	if day < 1 || day > 31 {
-		return fmt.Errorf("invalid day: %d, must be between 1 and 31", day)
-	}
+func NetworkSpeed() (totalUp, totalDown, upSpeed, downSpeed uint64, err error) {
+	cfg := flags.Current()
+	includeNics := parseNameList(cfg.IncludeNics)
+	excludeNics := parseNameList(cfg.ExcludeNics)
 
-	cmd := exec.Command("vnstat", "--config", fmt.Sprintf("MonthRotate %d", day))
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to set vnstat month rotate to day %d: %w", day, err)
+	// 如果设置了月重置（非0），使用流量账本统计本计费周期的 totalUp、totalDown
+	if cfg.MonthRotate != 0 {
+		usage, ledgerErr := MonthlyTraffic()
+		// 对于实时速度，仍然使用gopsutil方法
+		_, _, upSpeed, downSpeed, err = getNetworkSpeedFallback(includeNics, excludeNics)
+		return usage.Up, usage.Down, upSpeed, downSpeed, errors.Join(ledgerErr, err)
 	}
 
-	return nil
+	// 如果没有设置月重置，使用原来的方法
+	return getNetworkSpeedFallback(includeNics, excludeNics)
 }
 
-func NetworkSpeed() (totalUp, totalDown, upSpeed, downSpeed uint64, err error) {
-	includeNics := parseNics(flags.IncludeNics)
-	excludeNics := parseNics(flags.ExcludeNics)
-
-	// 如果设置了月重置（非0），使用vnstat统计totalUp、totalDown
-	if flags.MonthRotate != 0 {
-
-		vnstatData, err := getVnstatData()
-		if err != nil {
-			// 如果vnstat失败，回退到原来的方法，并返回额外的错误信息
-			fallbackUp, fallbackDown, fallbackUpSpeed, fallbackDownSpeed, fallbackErr := getNetworkSpeedFallback(includeNics, excludeNics)
-			if fallbackErr != nil {
-				return fallbackUp, fallbackDown, fallbackUpSpeed, fallbackDownSpeed, fmt.Errorf("failed to call vnstat: %v; fallback error: %w", err, fallbackErr)
-			}
-			return fallbackUp, fallbackDown, fallbackUpSpeed, fallbackDownSpeed, fmt.Errorf("failed to call vnstat: %w", err)
-		}
-
-		// 使用vnstat数据计算当月（到重置日）的流量使用量
-		for interfaceName, interfaceData := range vnstatData {
-			if shouldInclude(interfaceName, includeNics, excludeNics) {
-				monthlyRx, monthlyTx := calculateMonthlyUsage(interfaceData, flags.MonthRotate)
-				totalUp += monthlyTx
-				totalDown += monthlyRx
-			}
-		}
+// netSpeedTracker 保存上一次采样的累计流量，根据两次采样的差值计算速率
+type netSpeedTracker struct {
+	mu       sync.Mutex
+	at       time.Time
+	up, down uint64
+}
 
-		// 对于实时速度，仍然使用gopsutil方法
-		_, _, upSpeed, downSpeed, err = getNetworkSpeedFallback(includeNics, excludeNics)
-		if err != nil {
-			return totalUp, totalDown, 0, 0, err
+var speedTracker netSpeedTracker
+
+// update 记录本次累计流量，返回与上次采样之间的平均速率（字节/秒）。
+// 首次采样或计数器回退（网卡重置、过滤条件变化）时速率为 0。
+func (t *netSpeedTracker) update(up, down uint64, now time.Time) (upSpeed, downSpeed uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.at.IsZero() && up >= t.up && down >= t.down {
+		if elapsed := now.Sub(t.at).Seconds(); elapsed > 0 {
+			upSpeed = uint64(float64(up-t.up) / elapsed)
+			downSpeed = uint64(float64(down-t.down) / elapsed)
 		}
-
-		return totalUp, totalDown, upSpeed, downSpeed, nil
 	}
+	t.at, t.up, t.down = now, up, down
+	return upSpeed, downSpeed
+}
 
-	// 如果没有设置月重置，使用原来的方法
-	return getNetworkSpeedFallback(includeNics, excludeNics)
+// InterfaceInfo 单个网卡的流量与状态
+type InterfaceInfo struct {
+	Name string
+	// State 链路状态：up、no-carrier（已启用但无载波）、down 或 unknown
+	State string
+	// UpSpeed/DownSpeed 单位字节/秒
+	UpSpeed   uint64
+	DownSpeed uint64
+	// PacketsSent/PacketsRecv 单位包/秒
+	PacketsSent float64
+	PacketsRecv float64
+	// 以下为累计值
+	TotalUp   uint64
+	TotalDown uint64
+	Errin     uint64
+	Errout    uint64
+	Dropin    uint64
+	Dropout   uint64
 }
 
-func getNetworkSpeedFallback(includeNics, excludeNics map[string]struct{}) (totalUp, totalDown, upSpeed, downSpeed uint64, err error) {
-	// 获取第一次网络IO计数器
-	ioCounters1, err := net.IOCounters(true)
+// interfaceTracker 保存上一次采样的各网卡计数器，根据两次采样的差值计算速率
+type interfaceTracker struct {
+	mu   sync.Mutex
+	at   time.Time
+	last map[string]net.IOCountersStat
+}
+
+var ifaceTracker interfaceTracker
+
+// NetworkInterfaces 返回各网卡自上次调用以来的速率、累计计数与链路状态，不阻塞等待。
+// 与 NetworkSpeed 使用相同的网卡过滤规则。
+func NetworkInterfaces() ([]InterfaceInfo, error) {
+	cfg := flags.Current()
+	includeNics := parseNameList(cfg.IncludeNics)
+	excludeNics := parseNameList(cfg.ExcludeNics)
+	ioCounters, err := net.IOCounters(true)
 	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("failed to get network IO counters: %w", err)
+		return nil, fmt.Errorf("failed to get network IO counters: %w", err)
 	}
+	states := interfaceStates()
 
-	if len(ioCounters1) == 0 {
-		return 0, 0, 0, 0, fmt.Errorf("no network interfaces found")
+	cur := make(map[string]net.IOCountersStat, len(ioCounters))
+	for _, stat := range ioCounters {
+		if shouldInclude(stat.Name, includeNics, excludeNics) {
+			cur[stat.Name] = stat
+		}
+	}
+	infos := ifaceTracker.update(cur, time.Now())
+	for i := range infos {
+		infos[i].State = states[infos[i].Name]
+		if infos[i].State == "" {
+			infos[i].State = "unknown"
+		}
 	}
+	return infos, nil
+}
 
-	// 统计第一次所有非回环接口的流量
-	var totalUp1, totalDown1 uint64
-	for _, interfaceStats := range ioCounters1 {
-		if shouldInclude(interfaceStats.Name, includeNics, excludeNics) {
-			totalUp1 += interfaceStats.BytesSent
-			totalDown1 += interfaceStats.BytesRecv
+// interfaceStates 根据网卡标志判断链路状态
+func interfaceStates() map[string]string {
+	states := map[string]string{}
+	ifaces, err := stdnet.Interfaces()
+	if err != nil {
+		return states
+	}
+	for _, iface := range ifaces {
+		switch {
+		case iface.Flags&stdnet.FlagUp == 0:
+			states[iface.Name] = "down"
+		case iface.Flags&stdnet.FlagRunning == 0:
+			states[iface.Name] = "no-carrier"
+		default:
+			states[iface.Name] = "up"
 		}
 	}
+	return states
+}
 
-	// 等待1秒
-	time.Sleep(time.Second)
+// update 记录本次采样，返回按名称排序的各网卡数据。新出现的网卡或计数器回退时速率为 0。
+func (t *interfaceTracker) update(cur map[string]net.IOCountersStat, now time.Time) []InterfaceInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var elapsed float64
+	if !t.at.IsZero() {
+		elapsed = now.Sub(t.at).Seconds()
+	}
+	infos := make([]InterfaceInfo, 0, len(cur))
+	for name, c := range cur {
+		info := InterfaceInfo{
+			Name:      name,
+			TotalUp:   c.BytesSent,
+			TotalDown: c.BytesRecv,
+			Errin:     c.Errin,
+			Errout:    c.Errout,
+			Dropin:    c.Dropin,
+			Dropout:   c.Dropout,
+		}
+		if p, ok := t.last[name]; ok && elapsed > 0 &&
+			c.BytesSent >= p.BytesSent && c.BytesRecv >= p.BytesRecv &&
+			c.PacketsSent >= p.PacketsSent && c.PacketsRecv >= p.PacketsRecv {
+			info.UpSpeed = uint64(float64(c.BytesSent-p.BytesSent) / elapsed)
+			info.DownSpeed = uint64(float64(c.BytesRecv-p.BytesRecv) / elapsed)
+			info.PacketsSent = float64(c.PacketsSent-p.PacketsSent) / elapsed
+			info.PacketsRecv = float64(c.PacketsRecv-p.PacketsRecv) / elapsed
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	t.at, t.last = now, cur
+	return infos
+}
 
-	// 获取第二次网络IO计数器
-	ioCounters2, err := net.IOCounters(true)
+func getNetworkSpeedFallback(includeNics, excludeNics map[string]struct{}) (totalUp, totalDown, upSpeed, downSpeed uint64, err error) {
+	ioCounters, err := net.IOCounters(true)
 	if err != nil {
 		return 0, 0, 0, 0, fmt.Errorf("failed to get network IO counters: %w", err)
 	}
 
-	if len(ioCounters2) == 0 {
+	if len(ioCounters) == 0 {
 		return 0, 0, 0, 0, fmt.Errorf("no network interfaces found")
 	}
 
-	// 统计第二次所有非回环接口的流量
-	var totalUp2, totalDown2 uint64
-	for _, interfaceStats := range ioCounters2 {
+	// 统计所有非回环接口的流量
+	for _, interfaceStats := range ioCounters {
 		if shouldInclude(interfaceStats.Name, includeNics, excludeNics) {
-			totalUp2 += interfaceStats.BytesSent
-			totalDown2 += interfaceStats.BytesRecv
+			totalUp += interfaceStats.BytesSent
+			totalDown += interfaceStats.BytesRecv
 		}
 	}
 
-	// 计算速度 (每秒的速率)
-	upSpeed = totalUp2 - totalUp1
-	downSpeed = totalDown2 - totalDown1
+	// 与上一次采样比较计算速率，不再阻塞等待
+	upSpeed, downSpeed = speedTracker.update(totalUp, totalDown, time.Now())
 
-	return totalUp2, totalDown2, upSpeed, downSpeed, nil
+	return totalUp, totalDown, upSpeed, downSpeed, nil
 }
 
-func parseNics(nics string) map[string]struct{} {
-	if nics == "" {
+// parseNameList 解析逗号分隔的网卡或块设备名称列表，为空时返回 nil
+func parseNameList(list string) map[string]struct{} {
+	if list == "" {
 		return nil
 	}
-	nicSet := make(map[string]struct{})
-	for _, nic := range strings.Split(nics, ",") {
-		nicSet[strings.TrimSpace(nic)] = struct{}{}
+	names := make(map[string]struct{})
+	for _, name := range strings.Split(list, ",") {
+		names[strings.TrimSpace(name)] = struct{}{}
 	}
-	return nicSet
+	return names
 }
 
 func shouldInclude(nicName string, includeNics, excludeNics map[string]struct{}) bool {
@@ -324,20 +391,10 @@ func shouldInclude(nicName string, includeNics, excludeNics map[string]struct{})
 }
 
 func InterfaceList() ([]string, error) {
-	includeNics := parseNics(flags.IncludeNics)
-	excludeNics := parseNics(flags.ExcludeNics)
+	cfg := flags.Current()
+	includeNics := parseNameList(cfg.IncludeNics)
+	excludeNics := parseNameList(cfg.ExcludeNics)
 	interfaces := []string{}
-	if flags.MonthRotate != 0 {
-		vnstatData, err := getVnstatData()
-		if err == nil {
-			for interfaceName := range vnstatData {
-				if shouldInclude(interfaceName, includeNics, excludeNics) {
-					interfaces = append(interfaces, interfaceName)
-				}
-			}
-			return interfaces, nil
-		}
-	}
 	ioCounters, err := net.IOCounters(true)
 	if err != nil {
 		return nil, err
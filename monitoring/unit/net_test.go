@@ -1,10 +1,14 @@
 package monitoring
 
 import (
+	stdnet "net"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/net"
 )
 
 func TestConnectionsCount(t *testing.T) {
@@ -24,7 +28,63 @@ func TestConnectionsCount(t *testing.T) {
 	t.Logf("TCP connections: %d, UDP connections: %d", tcpCount, udpCount)
 }
 
-func TestParseNics(t *testing.T) {
+func TestConnectionsTCPStates(t *testing.T) {
+	info, err := Connections()
+	if err != nil {
+		t.Fatalf("Connections failed: %v", err)
+	}
+	sum := 0
+	for _, n := range info.TCPStates {
+		sum += n
+	}
+	if sum != info.TCP {
+		t.Errorf("TCP states sum to %d, want %d (%v)", sum, info.TCP, info.TCPStates)
+	}
+}
+
+func BenchmarkConnectionsFast(b *testing.B) {
+	if _, err := connectionsFast(); err != nil {
+		b.Skipf("fast path unavailable: %v", err)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := connectionsFast(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConnectionsGopsutil(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := connectionsSlow(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestListeningPorts(t *testing.T) {
+	ln, err := stdnet.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer ln.Close()
+	port := uint32(ln.Addr().(*stdnet.TCPAddr).Port)
+
+	ports, err := ListeningPorts()
+	if err != nil {
+		t.Fatalf("ListeningPorts failed: %v", err)
+	}
+	for _, p := range ports {
+		if p.Protocol == "tcp" && p.Port == port {
+			if p.PID == int32(os.Getpid()) && p.Process == "" {
+				t.Errorf("listener %+v has no process name", p)
+			}
+			return
+		}
+	}
+	t.Errorf("listener on port %d not found in %+v", port, ports)
+}
+
+func TestParseNameList(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
@@ -64,7 +124,7 @@ func TestParseNics(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseNics(tt.input)
+			result := parseNameList(tt.input)
 
 			if tt.expected == nil && result != nil {
 				t.Errorf("Expected nil, got %v", result)
@@ -190,13 +250,58 @@ func TestNetworkSpeedFallback(t *testing.T) {
 		totalUp, totalDown, upSpeed, downSpeed)
 }
 
+func TestNetSpeedTracker(t *testing.T) {
+	var tracker netSpeedTracker
+	now := time.Now()
+
+	if up, down := tracker.update(1000, 2000, now); up != 0 || down != 0 {
+		t.Errorf("first sample = %d/%d, want 0/0", up, down)
+	}
+	if up, down := tracker.update(3000, 6000, now.Add(2*time.Second)); up != 1000 || down != 2000 {
+		t.Errorf("speed = %d/%d, want 1000/2000", up, down)
+	}
+	// 计数器回退（网卡重置）时速率为 0
+	if up, down := tracker.update(10, 10, now.Add(3*time.Second)); up != 0 || down != 0 {
+		t.Errorf("speed after counter reset = %d/%d, want 0/0", up, down)
+	}
+}
+
+func TestInterfaceTracker(t *testing.T) {
+	var tracker interfaceTracker
+	now := time.Now()
+	tracker.update(map[string]net.IOCountersStat{
+		"eth0": {Name: "eth0", BytesSent: 1000, BytesRecv: 2000, PacketsSent: 10, PacketsRecv: 20},
+	}, now)
+
+	infos := tracker.update(map[string]net.IOCountersStat{
+		"eth0": {Name: "eth0", BytesSent: 3000, BytesRecv: 6000, PacketsSent: 30, PacketsRecv: 60, Errin: 2, Dropout: 1},
+		"wg0":  {Name: "wg0", BytesSent: 500},
+	}, now.Add(2*time.Second))
+	if len(infos) != 2 || infos[0].Name != "eth0" || infos[1].Name != "wg0" {
+		t.Fatalf("unexpected interfaces: %+v", infos)
+	}
+	eth0 := infos[0]
+	if eth0.UpSpeed != 1000 || eth0.DownSpeed != 2000 || eth0.PacketsSent != 10 || eth0.PacketsRecv != 20 {
+		t.Errorf("eth0 rates = %+v", eth0)
+	}
+	if eth0.Errin != 2 || eth0.Dropout != 1 || eth0.TotalUp != 3000 {
+		t.Errorf("eth0 counters = %+v", eth0)
+	}
+	// 新出现的网卡没有上一次采样，速率为 0
+	if infos[1].UpSpeed != 0 || infos[1].TotalUp != 500 {
+		t.Errorf("wg0 = %+v", infos[1])
+	}
+}
+
 func TestNetworkSpeedWithoutMonthRotate(t *testing.T) {
 
-	flags.MonthRotate = 1
+	flags.Update(func(c *flags.Config) { c.MonthRotate = 1 })
 
 	// 设置测试值
-	flags.IncludeNics = ""
-	flags.ExcludeNics = ""
+	flags.Update(func(c *flags.Config) {
+		c.IncludeNics = ""
+		c.ExcludeNics = ""
+	})
 
 	totalUp, totalDown, upSpeed, downSpeed, err := NetworkSpeed()
 	if err != nil {
@@ -209,21 +314,17 @@ func TestNetworkSpeedWithoutMonthRotate(t *testing.T) {
 
 func TestNetworkSpeedWithMonthRotate(t *testing.T) {
 	// 保存原始值
-	originalMonthRotate := flags.MonthRotate
-	originalIncludeNics := flags.IncludeNics
-	originalExcludeNics := flags.ExcludeNics
+	original := *flags.Current()
 
 	// 恢复原始值
-	defer func() {
-		flags.MonthRotate = originalMonthRotate
-		flags.IncludeNics = originalIncludeNics
-		flags.ExcludeNics = originalExcludeNics
-	}()
+	defer flags.Update(func(c *flags.Config) { *c = original })
 
 	// 设置测试值 - 启用月重置
-	flags.MonthRotate = 1
-	flags.IncludeNics = ""
-	flags.ExcludeNics = ""
+	flags.Update(func(c *flags.Config) {
+		c.MonthRotate = 1
+		c.IncludeNics = ""
+		c.ExcludeNics = ""
+	})
 
 	totalUp, totalDown, upSpeed, downSpeed, err := NetworkSpeed()
 
@@ -264,21 +365,17 @@ func TestGetVnstatData(t *testing.T) {
 
 func TestNetworkSpeedWithNicFilters(t *testing.T) {
 	// 保存原始值
-	originalMonthRotate := flags.MonthRotate
-	originalIncludeNics := flags.IncludeNics
-	originalExcludeNics := flags.ExcludeNics
+	original := *flags.Current()
 
 	// 恢复原始值
-	defer func() {
-		flags.MonthRotate = originalMonthRotate
-		flags.IncludeNics = originalIncludeNics
-		flags.ExcludeNics = originalExcludeNics
-	}()
+	defer flags.Update(func(c *flags.Config) { *c = original })
 
 	// 测试排除回环接口
-	flags.MonthRotate = 0
-	flags.IncludeNics = ""
-	flags.ExcludeNics = "lo,docker0"
+	flags.Update(func(c *flags.Config) {
+		c.MonthRotate = 0
+		c.IncludeNics = ""
+		c.ExcludeNics = "lo,docker0"
+	})
 
 	totalUp, totalDown, upSpeed, downSpeed, err := NetworkSpeed()
 	if err != nil {
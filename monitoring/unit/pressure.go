@@ -0,0 +1,147 @@
+package monitoring
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PressureLine PSI 文件中的一行，avg 为百分比，Total 为累计停顿时间（微秒）
+type PressureLine struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  uint64
+}
+
+// PressureStat 单个资源的 some/full 停顿
+type PressureStat struct {
+	Some PressureLine
+	// Full 旧内核的 cpu 文件没有 full 行，此时为 nil
+	Full *PressureLine
+}
+
+// PressureInfo cpu/memory/io 三类资源的 PSI，不可用的资源为 nil
+type PressureInfo struct {
+	CPU    *PressureStat
+	Memory *PressureStat
+	IO     *PressureStat
+}
+
+// Empty 三类资源都不可用
+func (p PressureInfo) Empty() bool {
+	return p.CPU == nil && p.Memory == nil && p.IO == nil
+}
+
+// SystemPressure 读取 /proc/pressure 下的全局 PSI。内核未启用 PSI 时返回空结果。
+func SystemPressure() PressureInfo {
+	return readPressureDir("/proc/pressure", "")
+}
+
+// CgroupPressure 读取当前进程所在 cgroup v2 的 *.pressure 文件。
+// 只有 cgroup v1 或所在 cgroup 没有 PSI 文件时返回空结果。
+func CgroupPressure() PressureInfo {
+	dir, err := cgroupV2Dir("/proc/self/cgroup", filepath.Join(sysfsRoot(), "fs", "cgroup"))
+	if err != nil {
+		return PressureInfo{}
+	}
+	return readPressureDir(dir, ".pressure")
+}
+
+// readPressureDir 读取 dir 下的 cpu、memory、io（加上 suffix）文件，读取失败的资源为 nil
+func readPressureDir(dir, suffix string) PressureInfo {
+	read := func(name string) *PressureStat {
+		stat, err := readPressureFile(filepath.Join(dir, name+suffix))
+		if err != nil {
+			return nil
+		}
+		return stat
+	}
+	return PressureInfo{CPU: read("cpu"), Memory: read("memory"), IO: read("io")}
+}
+
+// readPressureFile 解析形如 "some avg10=0.00 avg60=0.00 avg300=0.00 total=0" 的 PSI 文件
+func readPressureFile(path string) (*PressureStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat := &PressureStat{}
+	hasSome := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		line, err := parsePressureLine(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid PSI line in %s: %w", path, err)
+		}
+		switch fields[0] {
+		case "some":
+			stat.Some, hasSome = line, true
+		case "full":
+			stat.Full = &line
+		}
+	}
+	// 内核以 psi=0 启动时文件存在但读取返回 EOPNOTSUPP
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasSome {
+		return nil, fmt.Errorf("no some line in %s", path)
+	}
+	return stat, nil
+}
+
+func parsePressureLine(fields []string) (PressureLine, error) {
+	line := PressureLine{}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return line, fmt.Errorf("invalid field %q", field)
+		}
+		var err error
+		switch key {
+		case "avg10":
+			line.Avg10, err = strconv.ParseFloat(value, 64)
+		case "avg60":
+			line.Avg60, err = strconv.ParseFloat(value, 64)
+		case "avg300":
+			line.Avg300, err = strconv.ParseFloat(value, 64)
+		case "total":
+			line.Total, err = strconv.ParseUint(value, 10, 64)
+		}
+		if err != nil {
+			return line, fmt.Errorf("invalid field %q", field)
+		}
+	}
+	return line, nil
+}
+
+// cgroupV2Dir 根据 /proc/self/cgroup 中的 "0::<path>" 行定位 cgroup v2 目录。
+// 混合模式下 cgroup2 挂载在 <cgroupRoot>/unified。
+func cgroupV2Dir(procCgroup, cgroupRoot string) (string, error) {
+	data, err := os.ReadFile(procCgroup)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		path, ok := strings.CutPrefix(line, "0::")
+		if !ok {
+			continue
+		}
+		mount := cgroupRoot
+		if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+			mount = filepath.Join(cgroupRoot, "unified")
+		}
+		return filepath.Join(mount, filepath.FromSlash(strings.TrimSpace(path))), nil
+	}
+	return "", fmt.Errorf("no cgroup v2 entry in %s", procCgroup)
+}
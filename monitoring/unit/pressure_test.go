@@ -0,0 +1,58 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPressureDir(t *testing.T) {
+	dir := t.TempDir()
+	writeSysfs(t, dir, map[string]string{
+		// 5.13 之前的内核 cpu 文件只有 some 行
+		"cpu.pressure":    "some avg10=1.15 avg60=2.45 avg300=2.07 total=69986993",
+		"memory.pressure": "some avg10=0.00 avg60=0.10 avg300=0.05 total=1200\nfull avg10=0.00 avg60=0.02 avg300=0.01 total=300",
+		"io.pressure":     "garbage",
+	})
+	info := readPressureDir(dir, ".pressure")
+	if info.CPU == nil || info.CPU.Some != (PressureLine{Avg10: 1.15, Avg60: 2.45, Avg300: 2.07, Total: 69986993}) || info.CPU.Full != nil {
+		t.Errorf("cpu = %+v", info.CPU)
+	}
+	if info.Memory == nil || info.Memory.Full == nil || info.Memory.Full.Avg60 != 0.02 || info.Memory.Full.Total != 300 {
+		t.Errorf("memory = %+v", info.Memory)
+	}
+	if info.IO != nil {
+		t.Errorf("io = %+v, want nil for an unparsable file", info.IO)
+	}
+
+	if info := readPressureDir(filepath.Join(dir, "missing"), ""); !info.Empty() {
+		t.Errorf("missing dir = %+v, want empty", info)
+	}
+}
+
+func TestCgroupV2Dir(t *testing.T) {
+	root := t.TempDir()
+	procCgroup := filepath.Join(root, "cgroup")
+	cgroupRoot := filepath.Join(root, "fs", "cgroup")
+	if err := os.MkdirAll(cgroupRoot, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 混合模式：只有 v2 的 0:: 行生效，cgroup2 挂载在 unified 下
+	writeSysfs(t, root, map[string]string{"cgroup": "4:memory:/docker/abc\n0::/system.slice/komari-agent.service"})
+	dir, err := cgroupV2Dir(procCgroup, cgroupRoot)
+	if err != nil || dir != filepath.Join(cgroupRoot, "unified", "system.slice", "komari-agent.service") {
+		t.Errorf("hybrid dir = %q, %v", dir, err)
+	}
+
+	writeSysfs(t, root, map[string]string{"fs/cgroup/cgroup.controllers": "cpu io memory"})
+	dir, err = cgroupV2Dir(procCgroup, cgroupRoot)
+	if err != nil || dir != filepath.Join(cgroupRoot, "system.slice", "komari-agent.service") {
+		t.Errorf("unified dir = %q, %v", dir, err)
+	}
+
+	writeSysfs(t, root, map[string]string{"cgroup": "4:memory:/docker/abc"})
+	if _, err := cgroupV2Dir(procCgroup, cgroupRoot); err == nil {
+		t.Error("expected an error for cgroup v1 only")
+	}
+}
@@ -0,0 +1,179 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TemperatureInfo 温度传感器读数，单位摄氏度
+type TemperatureInfo struct {
+	// Sensor hwmon 设备名（如 coretemp、nvme）或 thermal zone 类型
+	Sensor string
+	// Label 传感器标签，未提供时为 temp1 之类的通道名
+	Label       string
+	Temperature float64
+	// High/Critical 告警与临界温度，未提供时为 0
+	High     float64
+	Critical float64
+}
+
+// FanInfo 风扇转速
+type FanInfo struct {
+	Sensor string
+	Label  string
+	RPM    uint64
+}
+
+// SensorsInfo 温度与风扇传感器读数
+type SensorsInfo struct {
+	Temperatures []TemperatureInfo
+	Fans         []FanInfo
+}
+
+// Sensors 读取 /sys/class/hwmon 与 /sys/class/thermal 中的温度和风扇数据
+func Sensors() (SensorsInfo, error) {
+	return readSensors(sysfsRoot())
+}
+
+func readSensors(root string) (SensorsInfo, error) {
+	info := SensorsInfo{}
+	hwmonNames := map[string]struct{}{}
+
+	hwmons, err := filepath.Glob(filepath.Join(root, "class", "hwmon", "hwmon*"))
+	if err != nil {
+		return info, err
+	}
+	sort.Strings(hwmons)
+	for _, dir := range hwmons {
+		// 旧内核把传感器文件放在 device 子目录下
+		if _, err := os.Stat(filepath.Join(dir, "name")); err != nil {
+			dir = filepath.Join(dir, "device")
+		}
+		name := readSysfsString(filepath.Join(dir, "name"))
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+		hwmonNames[name] = struct{}{}
+		info.Temperatures = append(info.Temperatures, hwmonTemperatures(dir, name)...)
+		info.Fans = append(info.Fans, hwmonFans(dir, name)...)
+	}
+
+	zones, err := filepath.Glob(filepath.Join(root, "class", "thermal", "thermal_zone*"))
+	if err != nil {
+		return info, err
+	}
+	sort.Strings(zones)
+	for _, dir := range zones {
+		zoneType := readSysfsString(filepath.Join(dir, "type"))
+		if zoneType == "" {
+			zoneType = filepath.Base(dir)
+		}
+		// thermal zone 通常也会以同名 hwmon 设备出现，避免重复上报
+		if _, ok := hwmonNames[zoneType]; ok {
+			continue
+		}
+		temp, ok := readSysfsMilli(filepath.Join(dir, "temp"))
+		if !ok {
+			continue
+		}
+		info.Temperatures = append(info.Temperatures, TemperatureInfo{
+			Sensor:      zoneType,
+			Label:       filepath.Base(dir),
+			Temperature: temp,
+			Critical:    thermalCriticalTrip(dir),
+		})
+	}
+	return info, nil
+}
+
+// hwmonTemperatures 读取 tempN_input 及对应的 label、max、crit
+func hwmonTemperatures(dir, name string) []TemperatureInfo {
+	inputs, _ := filepath.Glob(filepath.Join(dir, "temp*_input"))
+	sortChannels(inputs)
+	temps := make([]TemperatureInfo, 0, len(inputs))
+	for _, input := range inputs {
+		temp, ok := readSysfsMilli(input)
+		if !ok {
+			continue
+		}
+		prefix := strings.TrimSuffix(input, "_input")
+		high, _ := readSysfsMilli(prefix + "_max")
+		critical, _ := readSysfsMilli(prefix + "_crit")
+		temps = append(temps, TemperatureInfo{
+			Sensor:      name,
+			Label:       channelLabel(prefix),
+			Temperature: temp,
+			High:        high,
+			Critical:    critical,
+		})
+	}
+	return temps
+}
+
+// hwmonFans 读取 fanN_input 及对应的 label
+func hwmonFans(dir, name string) []FanInfo {
+	inputs, _ := filepath.Glob(filepath.Join(dir, "fan*_input"))
+	sortChannels(inputs)
+	fans := make([]FanInfo, 0, len(inputs))
+	for _, input := range inputs {
+		rpm, err := strconv.ParseUint(readSysfsString(input), 10, 64)
+		if err != nil {
+			continue
+		}
+		prefix := strings.TrimSuffix(input, "_input")
+		fans = append(fans, FanInfo{Sensor: name, Label: channelLabel(prefix), RPM: rpm})
+	}
+	return fans
+}
+
+// thermalCriticalTrip 返回 thermal zone 中类型为 critical 的触发点温度
+func thermalCriticalTrip(dir string) float64 {
+	types, _ := filepath.Glob(filepath.Join(dir, "trip_point_*_type"))
+	for _, typePath := range types {
+		if readSysfsString(typePath) != "critical" {
+			continue
+		}
+		if temp, ok := readSysfsMilli(strings.TrimSuffix(typePath, "_type") + "_temp"); ok {
+			return temp
+		}
+	}
+	return 0
+}
+
+// channelLabel 优先使用 <channel>_label，否则使用通道名本身
+func channelLabel(prefix string) string {
+	if label := readSysfsString(prefix + "_label"); label != "" {
+		return label
+	}
+	return filepath.Base(prefix)
+}
+
+// sortChannels 按通道序号排序，使 temp10 排在 temp2 之后
+func sortChannels(paths []string) {
+	index := func(path string) int {
+		base := strings.TrimLeft(filepath.Base(path), "abcdefghijklmnopqrstuvwxyz")
+		n, _ := strconv.Atoi(strings.SplitN(base, "_", 2)[0])
+		return n
+	}
+	sort.SliceStable(paths, func(i, j int) bool { return index(paths[i]) < index(paths[j]) })
+}
+
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readSysfsMilli 读取以千分之一为单位的数值，如毫摄氏度
+func readSysfsMilli(path string) (float64, bool) {
+	v, err := strconv.ParseFloat(readSysfsString(path), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v / 1000, true
+}
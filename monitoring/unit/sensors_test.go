@@ -0,0 +1,84 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSysfs 在 root 下按相对路径创建文件
+func writeSysfs(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadSensors(t *testing.T) {
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"class/hwmon/hwmon0/name":         "coretemp",
+		"class/hwmon/hwmon0/temp1_input":  "52000",
+		"class/hwmon/hwmon0/temp1_label":  "Package id 0",
+		"class/hwmon/hwmon0/temp1_max":    "84000",
+		"class/hwmon/hwmon0/temp1_crit":   "100000",
+		"class/hwmon/hwmon0/temp2_input":  "48500",
+		"class/hwmon/hwmon0/temp10_input": "47000",
+		// 旧内核的 device 子目录布局
+		"class/hwmon/hwmon1/device/name":                "nct6775",
+		"class/hwmon/hwmon1/device/fan1_input":          "1250",
+		"class/hwmon/hwmon1/device/fan1_label":          "CPU Fan",
+		"class/hwmon/hwmon1/device/fan2_input":          "0",
+		"class/hwmon/hwmon2/name":                       "acpitz",
+		"class/hwmon/hwmon2/temp1_input":                "27800",
+		"class/thermal/thermal_zone0/type":              "acpitz",
+		"class/thermal/thermal_zone0/temp":              "27800",
+		"class/thermal/thermal_zone1/type":              "x86_pkg_temp",
+		"class/thermal/thermal_zone1/temp":              "53000",
+		"class/thermal/thermal_zone1/trip_point_0_type": "passive",
+		"class/thermal/thermal_zone1/trip_point_0_temp": "90000",
+		"class/thermal/thermal_zone1/trip_point_1_type": "critical",
+		"class/thermal/thermal_zone1/trip_point_1_temp": "105000",
+	})
+
+	info, err := readSensors(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TemperatureInfo{
+		{Sensor: "coretemp", Label: "Package id 0", Temperature: 52, High: 84, Critical: 100},
+		{Sensor: "coretemp", Label: "temp2", Temperature: 48.5},
+		{Sensor: "coretemp", Label: "temp10", Temperature: 47},
+		{Sensor: "acpitz", Label: "temp1", Temperature: 27.8},
+		// acpitz 已通过 hwmon 上报，thermal_zone0 被跳过
+		{Sensor: "x86_pkg_temp", Label: "thermal_zone1", Temperature: 53, Critical: 105},
+	}
+	if len(info.Temperatures) != len(want) {
+		t.Fatalf("temperatures = %+v", info.Temperatures)
+	}
+	for i := range want {
+		if info.Temperatures[i] != want[i] {
+			t.Errorf("temperature %d = %+v, want %+v", i, info.Temperatures[i], want[i])
+		}
+	}
+	wantFans := []FanInfo{
+		{Sensor: "nct6775", Label: "CPU Fan", RPM: 1250},
+		{Sensor: "nct6775", Label: "fan2", RPM: 0},
+	}
+	if len(info.Fans) != len(wantFans) || info.Fans[0] != wantFans[0] || info.Fans[1] != wantFans[1] {
+		t.Errorf("fans = %+v, want %+v", info.Fans, wantFans)
+	}
+}
+
+func TestReadSensorsMissingTree(t *testing.T) {
+	info, err := readSensors(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(info.Temperatures) != 0 || len(info.Fans) != 0 {
+		t.Fatalf("readSensors = %+v, %v", info, err)
+	}
+}
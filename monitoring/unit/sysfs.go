@@ -0,0 +1,13 @@
+package monitoring
+
+import "github.com/komari-monitor/komari-agent/cmd/flags"
+
+// sysfsRoot 返回 sysfs 挂载点，默认为 /sys。传感器、磁盘分区识别、PSI、zram/zswap、
+// cgroup 限制与 systemd 单元的进程都从这里读取。
+func sysfsRoot() string {
+	cfg := flags.Current()
+	if cfg.SysfsRoot != "" {
+		return cfg.SysfsRoot
+	}
+	return "/sys"
+}
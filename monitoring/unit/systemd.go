@@ -0,0 +1,50 @@
+package monitoring
+
+import (
+	"os"
+	"strings"
+)
+
+// SystemdUnit systemd 单元的状态
+type SystemdUnit struct {
+	Name string
+	// LoadState loaded、not-found、masked 等，查询失败时为 unknown
+	LoadState string
+	// ActiveState active、failed、activating、inactive 等，查询失败时为 unknown
+	ActiveState string
+	SubState    string
+	// Result 服务上一次结束的原因，如 success、exit-code、signal，非 service 单元为空
+	Result string
+	// Restarts systemd 自动重启该服务的次数（NRestarts）
+	Restarts uint32
+	// ExitCode 主进程上一次的退出码或终止信号
+	ExitCode int32
+}
+
+// SystemdInfo 失败的单元与 --systemd-units 指定的单元
+type SystemdInfo struct {
+	Failed []SystemdUnit
+	Units  []SystemdUnit
+}
+
+// SystemdBooted 系统是否由 systemd 启动，与 sd_booted() 的判断方式相同
+func SystemdBooted() bool {
+	fi, err := os.Stat("/run/systemd/system")
+	return err == nil && fi.IsDir()
+}
+
+// parseUnitList 解析逗号分隔的单元名，未写类型后缀的视为 .service
+func parseUnitList(s string) []string {
+	var units []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !strings.Contains(name, ".") {
+			name += ".service"
+		}
+		units = append(units, name)
+	}
+	return units
+}
@@ -0,0 +1,147 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdDest    = "org.freedesktop.systemd1"
+	systemdPath    = dbus.ObjectPath("/org/freedesktop/systemd1")
+	systemdManager = "org.freedesktop.systemd1.Manager"
+)
+
+var (
+	systemBusMu sync.Mutex
+	systemBus   *dbus.Conn
+)
+
+// systemdConn 返回到系统总线的连接，断开后重新连接
+func systemdConn() (*dbus.Conn, error) {
+	systemBusMu.Lock()
+	defer systemBusMu.Unlock()
+	if systemBus != nil && systemBus.Connected() {
+		return systemBus, nil
+	}
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+	systemBus = conn
+	return conn, nil
+}
+
+// SystemdUnits 通过 D-Bus 查询处于 failed 状态的单元，以及 units（逗号分隔）中各单元的状态
+func SystemdUnits(ctx context.Context, units string) (SystemdInfo, error) {
+	info := SystemdInfo{}
+	conn, err := systemdConn()
+	if err != nil {
+		return info, err
+	}
+	manager := conn.Object(systemdDest, systemdPath)
+
+	var failed [][]interface{}
+	err = manager.CallWithContext(ctx, systemdManager+".ListUnitsFiltered", 0, []string{"failed"}).Store(&failed)
+	if err != nil {
+		return info, fmt.Errorf("failed to list failed units: %w", err)
+	}
+	var errs []error
+	for _, u := range failed {
+		unit, err := unitFromListEntry(u)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// ListUnits 不含服务的退出信息，单独查询
+		if path, ok := u[6].(dbus.ObjectPath); ok && strings.HasSuffix(unit.Name, ".service") {
+			if service, err := unitProperties(ctx, conn, path, "org.freedesktop.systemd1.Service"); err == nil {
+				applyServiceProperties(&unit, service)
+			}
+		}
+		info.Failed = append(info.Failed, unit)
+	}
+
+	info.Units, err = queryUnits(parseUnitList(units), func(name string) (SystemdUnit, error) {
+		return systemdUnit(ctx, conn, name)
+	})
+	return info, errors.Join(append(errs, err)...)
+}
+
+// queryUnits 逐个查询单元，查询失败的单元状态记为 unknown，不影响其余单元，返回全部错误
+func queryUnits(names []string, query func(name string) (SystemdUnit, error)) ([]SystemdUnit, error) {
+	units := make([]SystemdUnit, 0, len(names))
+	var errs []error
+	for _, name := range names {
+		unit, err := query(name)
+		if err != nil {
+			errs = append(errs, err)
+			unit = SystemdUnit{Name: name, LoadState: "unknown", ActiveState: "unknown"}
+		}
+		units = append(units, unit)
+	}
+	return units, errors.Join(errs...)
+}
+
+// systemdUnit 查询单个单元，单元未加载时由 LoadUnit 加载，不存在的单元 LoadState 为 not-found
+func systemdUnit(ctx context.Context, conn *dbus.Conn, name string) (SystemdUnit, error) {
+	var path dbus.ObjectPath
+	err := conn.Object(systemdDest, systemdPath).CallWithContext(ctx, systemdManager+".LoadUnit", 0, name).Store(&path)
+	if err != nil {
+		return SystemdUnit{Name: name}, fmt.Errorf("failed to load unit %s: %w", name, err)
+	}
+	props, err := unitProperties(ctx, conn, path, "org.freedesktop.systemd1.Unit")
+	if err != nil {
+		return SystemdUnit{Name: name}, fmt.Errorf("failed to get properties of %s: %w", name, err)
+	}
+	unit := SystemdUnit{Name: name}
+	applyUnitProperties(&unit, props)
+	if strings.HasSuffix(name, ".service") {
+		if service, err := unitProperties(ctx, conn, path, "org.freedesktop.systemd1.Service"); err == nil {
+			applyServiceProperties(&unit, service)
+		}
+	}
+	return unit, nil
+}
+
+func unitProperties(ctx context.Context, conn *dbus.Conn, path dbus.ObjectPath, iface string) (map[string]dbus.Variant, error) {
+	var props map[string]dbus.Variant
+	err := conn.Object(systemdDest, path).CallWithContext(ctx, "org.freedesktop.DBus.Properties.GetAll", 0, iface).Store(&props)
+	return props, err
+}
+
+// unitFromListEntry 解析 ListUnits 返回的 (name, description, load_state, active_state, sub_state, following, path, ...) 元组
+func unitFromListEntry(entry []interface{}) (SystemdUnit, error) {
+	if len(entry) < 7 {
+		return SystemdUnit{}, fmt.Errorf("unexpected ListUnits entry with %d fields", len(entry))
+	}
+	str := func(i int) string {
+		s, _ := entry[i].(string)
+		return s
+	}
+	return SystemdUnit{Name: str(0), LoadState: str(2), ActiveState: str(3), SubState: str(4)}, nil
+}
+
+func applyUnitProperties(unit *SystemdUnit, props map[string]dbus.Variant) {
+	str := func(key string) string {
+		s, _ := props[key].Value().(string)
+		return s
+	}
+	unit.LoadState = str("LoadState")
+	unit.ActiveState = str("ActiveState")
+	unit.SubState = str("SubState")
+}
+
+func applyServiceProperties(unit *SystemdUnit, props map[string]dbus.Variant) {
+	unit.Result, _ = props["Result"].Value().(string)
+	// NRestarts 在 systemd 235 加入
+	unit.Restarts, _ = props["NRestarts"].Value().(uint32)
+	unit.ExitCode, _ = props["ExecMainStatus"].Value().(int32)
+}
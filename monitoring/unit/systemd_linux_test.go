@@ -0,0 +1,75 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestParseUnitList(t *testing.T) {
+	got := parseUnitList(" nginx, docker.socket,,backup.timer ")
+	want := []string{"nginx.service", "docker.socket", "backup.timer"}
+	if len(got) != len(want) {
+		t.Fatalf("parseUnitList = %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unit %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSystemdUnitFromDBus(t *testing.T) {
+	entry := []interface{}{"nginx.service", "A high performance web server", "loaded", "failed", "failed", "",
+		dbus.ObjectPath("/org/freedesktop/systemd1/unit/nginx_2eservice"), uint32(0), "", dbus.ObjectPath("/")}
+	unit, err := unitFromListEntry(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyServiceProperties(&unit, map[string]dbus.Variant{
+		"Result":         dbus.MakeVariant("exit-code"),
+		"NRestarts":      dbus.MakeVariant(uint32(5)),
+		"ExecMainStatus": dbus.MakeVariant(int32(1)),
+	})
+	want := SystemdUnit{Name: "nginx.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed", Result: "exit-code", Restarts: 5, ExitCode: 1}
+	if unit != want {
+		t.Errorf("unit = %+v, want %+v", unit, want)
+	}
+
+	if _, err := unitFromListEntry([]interface{}{"short"}); err == nil {
+		t.Error("expected an error for a malformed entry")
+	}
+
+	// 旧版 systemd 没有 NRestarts
+	unit = SystemdUnit{Name: "redis.service"}
+	applyUnitProperties(&unit, map[string]dbus.Variant{
+		"LoadState":   dbus.MakeVariant("loaded"),
+		"ActiveState": dbus.MakeVariant("activating"),
+		"SubState":    dbus.MakeVariant("auto-restart"),
+	})
+	applyServiceProperties(&unit, map[string]dbus.Variant{"Result": dbus.MakeVariant("signal"), "ExecMainStatus": dbus.MakeVariant(int32(9))})
+	want = SystemdUnit{Name: "redis.service", LoadState: "loaded", ActiveState: "activating", SubState: "auto-restart", Result: "signal", ExitCode: 9}
+	if unit != want {
+		t.Errorf("unit = %+v, want %+v", unit, want)
+	}
+}
+
+func TestQueryUnitsKeepsOtherUnits(t *testing.T) {
+	units, err := queryUnits([]string{"nginx.service", "broken.service", "redis.service"}, func(name string) (SystemdUnit, error) {
+		if name == "broken.service" {
+			return SystemdUnit{}, fmt.Errorf("failed to load unit %s: access denied", name)
+		}
+		return SystemdUnit{Name: name, LoadState: "loaded", ActiveState: "active"}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "broken.service") {
+		t.Errorf("err = %v, want the error of broken.service", err)
+	}
+	if len(units) != 3 || units[0].ActiveState != "active" || units[2].ActiveState != "active" {
+		t.Fatalf("units = %+v, want all three", units)
+	}
+	if units[1].Name != "broken.service" || units[1].ActiveState != "unknown" {
+		t.Errorf("failed unit = %+v", units[1])
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+import (
+	"context"
+	"errors"
+)
+
+// SystemdUnits 非 Linux 平台没有 systemd
+func SystemdUnits(ctx context.Context, units string) (SystemdInfo, error) {
+	return SystemdInfo{}, errors.ErrUnsupported
+}
@@ -0,0 +1,160 @@
+package monitoring
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// maxCmdlineLength 上报的命令行最大长度
+const maxCmdlineLength = 256
+
+// ProcessInfo 单个进程的资源占用
+type ProcessInfo struct {
+	PID     int32
+	Name    string
+	User    string
+	Cmdline string
+	// CPU 两次采集之间的 CPU 使用率，100 表示占满一个核心
+	CPU     float64
+	RSS     uint64
+	Threads int32
+	// FDs 打开的文件描述符数，无权限读取时为 0
+	FDs int32
+}
+
+// processCPUSample 进程累计 CPU 时间（秒）
+type processCPUSample struct {
+	total float64
+	at    time.Time
+}
+
+// processCPUTracker 保存上一次采集时各进程的 CPU 时间，用于计算区间内的使用率
+type processCPUTracker struct {
+	mu   sync.Mutex
+	last map[int32]processCPUSample
+}
+
+var topTracker processCPUTracker
+
+// TopProcesses 返回按 CPU 使用率与 RSS 排序的前 n 个进程，命令行经 redact 处理。
+// CPU 使用率按与上一次调用之间的区间计算，首次调用只记录基准并返回 ok=false。
+func TopProcesses(ctx context.Context, n int, redact []*regexp.Regexp) (byCPU, byMemory []ProcessInfo, ok bool, err error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	now := time.Now()
+	type sample struct {
+		p   *process.Process
+		cpu float64
+		rss uint64
+	}
+	samples := make([]sample, 0, len(procs))
+	current := make(map[int32]processCPUSample, len(procs))
+
+	topTracker.mu.Lock()
+	last := topTracker.last
+	for _, p := range procs {
+		if ctx.Err() != nil {
+			topTracker.mu.Unlock()
+			return nil, nil, false, ctx.Err()
+		}
+		times, err := p.TimesWithContext(ctx)
+		if err != nil {
+			// 进程已退出
+			continue
+		}
+		total := times.User + times.System
+		current[p.Pid] = processCPUSample{total: total, at: now}
+		s := sample{p: p}
+		// 累计时间变小说明 PID 已被新进程复用，本次不计算使用率
+		if prev, seen := last[p.Pid]; seen && total >= prev.total {
+			if elapsed := now.Sub(prev.at).Seconds(); elapsed > 0 {
+				s.cpu = (total - prev.total) / elapsed * 100
+			}
+		}
+		if mem, err := p.MemoryInfoWithContext(ctx); err == nil {
+			s.rss = mem.RSS
+		}
+		samples = append(samples, s)
+	}
+	topTracker.last = current
+	topTracker.mu.Unlock()
+	if last == nil {
+		return nil, nil, false, nil
+	}
+
+	details := map[int32]ProcessInfo{}
+	info := func(s sample) ProcessInfo {
+		if pi, ok := details[s.p.Pid]; ok {
+			return pi
+		}
+		pi := processDetail(ctx, s.p, redact)
+		pi.CPU, pi.RSS = s.cpu, s.rss
+		details[s.p.Pid] = pi
+		return pi
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].cpu > samples[j].cpu })
+	for i := 0; i < n && i < len(samples); i++ {
+		byCPU = append(byCPU, info(samples[i]))
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].rss > samples[j].rss })
+	for i := 0; i < n && i < len(samples); i++ {
+		byMemory = append(byMemory, info(samples[i]))
+	}
+	return byCPU, byMemory, true, nil
+}
+
+// processDetail 读取进程名、用户、命令行、线程数和文件描述符数，读取失败的项留空
+func processDetail(ctx context.Context, p *process.Process, redact []*regexp.Regexp) ProcessInfo {
+	pi := ProcessInfo{PID: p.Pid}
+	pi.Name, _ = p.NameWithContext(ctx)
+	pi.User, _ = p.UsernameWithContext(ctx)
+	if args, err := p.CmdlineSliceWithContext(ctx); err == nil {
+		pi.Cmdline = RedactCmdline(args, redact)
+	}
+	pi.Threads, _ = p.NumThreadsWithContext(ctx)
+	pi.FDs, _ = p.NumFDsWithContext(ctx)
+	return pi
+}
+
+// secretArg 名称中带有这些词的参数视为敏感参数
+var secretArg = regexp.MustCompile(`(?i)^-{0,2}[\w.-]*(pass(wd|word)?|secret|token|api[_-]?key|auth|credential)[\w.-]*$`)
+
+// RedactCmdline 拼接命令行并隐藏敏感内容，结果截断到 maxCmdlineLength。
+// 名称像密码、token 的参数（--password=x、--token x、PASSWORD=x）的值替换为 ***，
+// 此外 patterns 的匹配内容也替换为 ***。
+func RedactCmdline(args []string, patterns []*regexp.Regexp) string {
+	redacted := make([]string, 0, len(args))
+	hideNext := false
+	for _, arg := range args {
+		switch {
+		case hideNext && !strings.HasPrefix(arg, "-"):
+			arg = "***"
+			hideNext = false
+		default:
+			hideNext = false
+			if key, _, ok := strings.Cut(arg, "="); ok && secretArg.MatchString(key) {
+				arg = key + "=***"
+			} else if strings.HasPrefix(arg, "-") && secretArg.MatchString(arg) {
+				hideNext = true
+			}
+		}
+		redacted = append(redacted, arg)
+	}
+	cmdline := strings.Join(redacted, " ")
+	for _, re := range patterns {
+		cmdline = re.ReplaceAllString(cmdline, "***")
+	}
+	if len(cmdline) > maxCmdlineLength {
+		cmdline = strings.ToValidUTF8(cmdline[:maxCmdlineLength], "") + "..."
+	}
+	return cmdline
+}
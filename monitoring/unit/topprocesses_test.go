@@ -0,0 +1,54 @@
+package monitoring
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactCmdline(t *testing.T) {
+	tests := []struct {
+		args     []string
+		patterns []*regexp.Regexp
+		want     string
+	}{
+		{[]string{"nginx", "-g", "daemon off;"}, nil, "nginx -g daemon off;"},
+		{[]string{"mysql", "--user=root", "--password=hunter2"}, nil, "mysql --user=root --password=***"},
+		{[]string{"agent", "--token", "abc", "--endpoint", "https://x"}, nil, "agent --token *** --endpoint https://x"},
+		{[]string{"env", "API_KEY=abc", "DB_PASS=x", "run"}, nil, "env API_KEY=*** DB_PASS=*** run"},
+		// 参数后紧跟的是另一个参数时不隐藏
+		{[]string{"app", "--no-auth", "--verbose"}, nil, "app --no-auth --verbose"},
+		{[]string{"psql", "postgres://u:secret@db/app"}, []*regexp.Regexp{regexp.MustCompile(`://[^@/]+@`)}, "psql postgres***db/app"},
+	}
+	for _, tt := range tests {
+		if got := RedactCmdline(tt.args, tt.patterns); got != tt.want {
+			t.Errorf("RedactCmdline(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	long := RedactCmdline([]string{strings.Repeat("a", 300)}, nil)
+	if len(long) != maxCmdlineLength+3 || !strings.HasSuffix(long, "...") {
+		t.Errorf("long cmdline not truncated: %d bytes", len(long))
+	}
+}
+
+func TestTopProcesses(t *testing.T) {
+	topTracker = processCPUTracker{}
+	if _, _, ok, err := TopProcesses(context.Background(), 3, nil); err != nil || ok {
+		t.Fatalf("first call = ok %v, err %v; want baseline only", ok, err)
+	}
+	byCPU, byMemory, ok, err := TopProcesses(context.Background(), 3, nil)
+	if err != nil || !ok {
+		t.Fatalf("second call = ok %v, err %v", ok, err)
+	}
+	if len(byCPU) == 0 || len(byCPU) > 3 || len(byMemory) == 0 || len(byMemory) > 3 {
+		t.Fatalf("byCPU = %+v, byMemory = %+v", byCPU, byMemory)
+	}
+	for i := 1; i < len(byMemory); i++ {
+		if byMemory[i].RSS > byMemory[i-1].RSS {
+			t.Errorf("byMemory not sorted: %+v", byMemory)
+		}
+	}
+	t.Logf("top by memory: %+v", byMemory)
+}
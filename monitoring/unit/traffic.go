@@ -0,0 +1,244 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// ledgerSaveInterval 流量账本写盘的最短间隔。异常断电时最多丢失这段时间内的流量。
+const ledgerSaveInterval = time.Minute
+
+// bootTimeTolerance 开机时间的允许误差（秒）。NTP 校时后 Linux 的开机时间可能变化一秒，
+// Windows 上开机时间由当前时间减去运行时间得出，每次读取都会漂移，不能视为重启。
+const bootTimeTolerance = 60
+
+// TrafficUsage 当前计费周期内的累计流量，单位字节
+type TrafficUsage struct {
+	Up          uint64
+	Down        uint64
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+// ifaceLedger 单个网卡在当前周期内的流量及上一次读到的内核计数器
+type ifaceLedger struct {
+	Rx     uint64 `json:"rx"`
+	Tx     uint64 `json:"tx"`
+	LastRx uint64 `json:"last_rx"`
+	LastTx uint64 `json:"last_tx"`
+	// BootTime 读取计数器时的开机时间（Unix 秒），变化超过 bootTimeTolerance 说明系统已重启、计数器已从 0 开始
+	BootTime uint64 `json:"boot_time"`
+}
+
+// trafficLedger 持久化的按网卡流量账本，替代 vnstat 统计计费周期内的流量
+type trafficLedger struct {
+	mu       sync.Mutex
+	path     string
+	lastSave time.Time
+
+	PeriodStart time.Time               `json:"period_start"`
+	Interfaces  map[string]*ifaceLedger `json:"interfaces"`
+}
+
+var (
+	ledgerMu sync.Mutex
+	ledger   *trafficLedger
+)
+
+// TrafficLedgerPath 返回账本文件路径，未指定时保存在程序所在目录
+func TrafficLedgerPath() string {
+	cfg := flags.Current()
+	if cfg.TrafficLedger != "" {
+		return cfg.TrafficLedger
+	}
+	execPath, err := os.Executable()
+	if err != nil {
+		return "traffic-ledger.json"
+	}
+	return filepath.Join(filepath.Dir(execPath), "traffic-ledger.json")
+}
+
+// getTrafficLedger 返回当前路径对应的账本，路径变化时重新加载
+func getTrafficLedger() *trafficLedger {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+	path := TrafficLedgerPath()
+	if ledger == nil || ledger.path != path {
+		ledger = loadTrafficLedger(path)
+	}
+	return ledger
+}
+
+// loadTrafficLedger 读取账本，文件不存在时尝试用 vnstat 的数据初始化本周期的流量
+func loadTrafficLedger(path string) *trafficLedger {
+	cfg := flags.Current()
+	l := &trafficLedger{path: path, Interfaces: map[string]*ifaceLedger{}}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, l); err != nil {
+			log.Println("Failed to parse traffic ledger, starting a new one:", err)
+			l.Interfaces = map[string]*ifaceLedger{}
+		}
+		if l.Interfaces == nil {
+			l.Interfaces = map[string]*ifaceLedger{}
+		}
+		return l
+	}
+	if !os.IsNotExist(err) {
+		log.Println("Failed to read traffic ledger:", err)
+		return l
+	}
+	if vnstatData, err := getVnstatData(); err == nil && cfg.MonthRotate != 0 {
+		for name, iface := range vnstatData {
+			rx, tx := calculateMonthlyUsage(iface, cfg.MonthRotate)
+			l.Interfaces[name] = &ifaceLedger{Rx: rx, Tx: tx}
+		}
+		l.PeriodStart = periodStart(time.Now(), cfg.MonthRotate)
+		log.Printf("Traffic ledger seeded from vnstat for %d interfaces", len(vnstatData))
+	}
+	return l
+}
+
+// MonthlyTraffic 返回当前计费周期内通过过滤条件的网卡的累计流量，并更新持久化账本。
+// 计费周期从每月 --month-rotate 日 0 点开始，该日超出当月天数时取当月最后一天。
+func MonthlyTraffic() (TrafficUsage, error) {
+	cfg := flags.Current()
+	includeNics := parseNameList(cfg.IncludeNics)
+	excludeNics := parseNameList(cfg.ExcludeNics)
+	ioCounters, err := net.IOCounters(true)
+	if err != nil {
+		return TrafficUsage{}, fmt.Errorf("failed to get network IO counters: %w", err)
+	}
+	bootTime, err := host.BootTime()
+	if err != nil {
+		return TrafficUsage{}, fmt.Errorf("failed to get boot time: %w", err)
+	}
+
+	l := getTrafficLedger()
+	usage := l.update(ioCounters, bootTime, cfg.MonthRotate, time.Now(), func(name string) bool {
+		return shouldInclude(name, includeNics, excludeNics)
+	})
+	return usage, l.saveIfDue(time.Now())
+}
+
+// update 将最新的计数器计入账本，返回 include 接受的网卡在当前周期内的流量合计
+func (l *trafficLedger) update(counters []net.IOCountersStat, bootTime uint64, rotateDay int, now time.Time, include func(string) bool) TrafficUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := periodStart(now, rotateDay)
+	if !l.PeriodStart.Equal(start) {
+		// 进入新的计费周期，清零累计流量，保留计数器基准
+		for _, iface := range l.Interfaces {
+			iface.Rx, iface.Tx = 0, 0
+		}
+		l.PeriodStart = start
+		l.lastSave = time.Time{}
+	}
+
+	usage := TrafficUsage{PeriodStart: start, PeriodEnd: nextPeriodStart(start, rotateDay)}
+	for _, c := range counters {
+		iface, ok := l.Interfaces[c.Name]
+		if !ok {
+			iface = &ifaceLedger{}
+			l.Interfaces[c.Name] = iface
+		}
+		if iface.BootTime == 0 {
+			// 首次见到的网卡（或从 vnstat 导入的记录）没有计数器基准，
+			// 无法得知开机以来有多少流量属于本周期，从现在开始统计
+			l.lastSave = time.Time{}
+		} else {
+			rebooted := absDiff(iface.BootTime, bootTime) > bootTimeTolerance
+			iface.Rx += counterDelta(iface.LastRx, c.BytesRecv, rebooted)
+			iface.Tx += counterDelta(iface.LastTx, c.BytesSent, rebooted)
+		}
+		iface.LastRx, iface.LastTx, iface.BootTime = c.BytesRecv, c.BytesSent, bootTime
+
+		if include(c.Name) {
+			usage.Up += iface.Tx
+			usage.Down += iface.Rx
+		}
+	}
+	return usage
+}
+
+// counterDelta 计算计数器增量。重启后计数器从 0 开始；
+// 计数器变小时，若上次读数接近 32 位上限视为回绕，否则视为网卡重置后从 0 开始。
+func counterDelta(last, cur uint64, rebooted bool) uint64 {
+	switch {
+	case rebooted:
+		return cur
+	case cur >= last:
+		return cur - last
+	case last <= math.MaxUint32 && last > math.MaxUint32/4*3:
+		return math.MaxUint32 - last + cur + 1
+	default:
+		return cur
+	}
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// saveIfDue 距上次写盘超过 ledgerSaveInterval 时写入账本
+func (l *trafficLedger) saveIfDue(now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSave) < ledgerSaveInterval {
+		return nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再重命名，避免写入中途断电损坏账本
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to save traffic ledger: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("failed to save traffic ledger: %w", err)
+	}
+	l.lastSave = now
+	return nil
+}
+
+// periodStart 返回 now 所在计费周期的起始时间
+func periodStart(now time.Time, rotateDay int) time.Time {
+	start := rotateDate(now.Year(), now.Month(), rotateDay, now.Location())
+	if now.Before(start) {
+		start = rotateDate(now.Year(), now.Month()-1, rotateDay, now.Location())
+	}
+	return start
+}
+
+// nextPeriodStart 返回下一个计费周期的起始时间
+func nextPeriodStart(start time.Time, rotateDay int) time.Time {
+	return rotateDate(start.Year(), start.Month()+1, rotateDay, start.Location())
+}
+
+// rotateDate 返回指定月份的重置日 0 点，重置日超出当月天数时取当月最后一天
+func rotateDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	if day < 1 {
+		day = 1
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, loc)
+}
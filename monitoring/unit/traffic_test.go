@@ -0,0 +1,126 @@
+package monitoring
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func includeAll(string) bool { return true }
+
+func TestTrafficLedgerCountsAcrossRebootsAndWraps(t *testing.T) {
+	l := &trafficLedger{Interfaces: map[string]*ifaceLedger{}}
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	counters := func(rx, tx uint64) []net.IOCountersStat {
+		return []net.IOCountersStat{{Name: "eth0", BytesRecv: rx, BytesSent: tx}}
+	}
+
+	// 首次见到的网卡从当前读数开始统计
+	if u := l.update(counters(5000, 1000), 100, 1, now, includeAll); u.Down != 0 || u.Up != 0 {
+		t.Fatalf("first sample = %+v, want zero usage", u)
+	}
+	if u := l.update(counters(8000, 1500), 100, 1, now.Add(time.Minute), includeAll); u.Down != 3000 || u.Up != 500 {
+		t.Fatalf("usage = %+v, want down 3000 up 500", u)
+	}
+	// 重启后计数器从 0 开始
+	if u := l.update(counters(200, 100), 900, 1, now.Add(time.Hour), includeAll); u.Down != 3200 || u.Up != 600 {
+		t.Fatalf("usage after reboot = %+v, want down 3200 up 600", u)
+	}
+	// 32 位计数器回绕
+	l.Interfaces["eth0"].LastRx = math.MaxUint32 - 99
+	if u := l.update(counters(50, 100), 900, 1, now.Add(2*time.Hour), includeAll); u.Down != 3200+150 {
+		t.Fatalf("usage after wrap = %+v, want down %d", u, 3200+150)
+	}
+	// 网卡被过滤时不计入合计，但账本继续记录
+	if u := l.update(counters(150, 100), 900, 1, now.Add(3*time.Hour), func(string) bool { return false }); u.Down != 0 {
+		t.Fatalf("excluded interface counted: %+v", u)
+	}
+	if l.Interfaces["eth0"].Rx != 3450 {
+		t.Fatalf("ledger Rx = %d, want 3450", l.Interfaces["eth0"].Rx)
+	}
+	// 进入新的计费周期后清零
+	u := l.update(counters(250, 100), 900, 1, time.Date(2024, 4, 1, 0, 0, 1, 0, time.UTC), includeAll)
+	if u.Down != 100 || u.Up != 0 {
+		t.Fatalf("usage in new period = %+v, want down 100", u)
+	}
+	if !u.PeriodStart.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) || !u.PeriodEnd.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("period = %v - %v", u.PeriodStart, u.PeriodEnd)
+	}
+}
+
+func TestTrafficLedgerIgnoresBootTimeJitter(t *testing.T) {
+	l := &trafficLedger{Interfaces: map[string]*ifaceLedger{}}
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	counters := func(rx uint64) []net.IOCountersStat {
+		return []net.IOCountersStat{{Name: "eth0", BytesRecv: rx}}
+	}
+
+	l.update(counters(1<<30), 1000, 1, now, includeAll)
+	// NTP 校时或 Windows 上的计算误差使开机时间偏移 1 秒，不应重复计入开机以来的流量
+	if u := l.update(counters(1<<30+500), 1001, 1, now.Add(time.Minute), includeAll); u.Down != 500 {
+		t.Fatalf("usage after 1s boot time jitter = %+v, want down 500", u)
+	}
+	if u := l.update(counters(1<<30+800), 999, 1, now.Add(2*time.Minute), includeAll); u.Down != 800 {
+		t.Fatalf("usage after -2s boot time jitter = %+v, want down 800", u)
+	}
+	// 开机时间未超出误差但计数器变小，按网卡重置处理
+	if u := l.update(counters(100), 1000, 1, now.Add(3*time.Minute), includeAll); u.Down != 900 {
+		t.Fatalf("usage after counter reset = %+v, want down 900", u)
+	}
+}
+
+func TestTrafficLedgerSeededEntriesNeedBaseline(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	// 从 vnstat 导入的记录只有本周期的流量，没有计数器基准
+	l := &trafficLedger{
+		PeriodStart: periodStart(now, 1),
+		Interfaces:  map[string]*ifaceLedger{"eth0": {Rx: 1000, Tx: 500}},
+	}
+	u := l.update([]net.IOCountersStat{{Name: "eth0", BytesRecv: 1 << 30, BytesSent: 1 << 20}}, 100, 1, now, includeAll)
+	if u.Down != 1000 || u.Up != 500 {
+		t.Fatalf("usage = %+v, want the seeded values", u)
+	}
+}
+
+func TestTrafficLedgerPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	l := loadTrafficLedger(path)
+	l.update([]net.IOCountersStat{{Name: "eth0", BytesRecv: 10, BytesSent: 20}}, 100, 1, now, includeAll)
+	l.update([]net.IOCountersStat{{Name: "eth0", BytesRecv: 110, BytesSent: 220}}, 100, 1, now, includeAll)
+	if err := l.saveIfDue(now); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := loadTrafficLedger(path)
+	iface := reloaded.Interfaces["eth0"]
+	if iface == nil || iface.Rx != 100 || iface.Tx != 200 || iface.LastRx != 110 || iface.BootTime != 100 {
+		t.Fatalf("reloaded ledger = %+v", iface)
+	}
+	if !reloaded.PeriodStart.Equal(l.PeriodStart) {
+		t.Errorf("period start = %v, want %v", reloaded.PeriodStart, l.PeriodStart)
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	tests := []struct {
+		now       time.Time
+		rotateDay int
+		want      time.Time
+	}{
+		{time.Date(2024, 3, 15, 8, 0, 0, 0, time.UTC), 10, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC), 10, time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 5, 8, 0, 0, 0, time.UTC), 10, time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)},
+		// 重置日超出当月天数时取当月最后一天
+		{time.Date(2024, 2, 29, 8, 0, 0, 0, time.UTC), 31, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 30, 8, 0, 0, 0, time.UTC), 31, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := periodStart(tt.now, tt.rotateDay); !got.Equal(tt.want) {
+			t.Errorf("periodStart(%v, %d) = %v, want %v", tt.now, tt.rotateDay, got, tt.want)
+		}
+	}
+}
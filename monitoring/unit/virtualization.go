@@ -84,6 +84,10 @@ func detectContainer() string {
 	if s := parseCgroupForContainer(); s != "" {
 		return s
 	}
+	// systemd 约定由容器管理器写入的标记，LXC、nspawn 等会设置
+	if ct := systemdContainerMarker(); ct != "" {
+		return ct
+	}
 	if fileExists("/.komari-agent-container") {
 		return "container"
 	}
@@ -98,12 +102,37 @@ func fileExists(p string) bool {
 	return false
 }
 
+// systemdContainerMarker 读取 /run/systemd/container 或 PID 1 环境变量中的 container=
+func systemdContainerMarker() string {
+	if data, err := os.ReadFile("/run/systemd/container"); err == nil {
+		if ct := strings.TrimSpace(string(data)); ct != "" {
+			return ct
+		}
+	}
+	// 读取 /proc/1/environ 通常需要 root 权限，失败时忽略
+	data, err := os.ReadFile("/proc/1/environ")
+	if err != nil {
+		return ""
+	}
+	for _, env := range strings.Split(string(data), "\x00") {
+		if ct, ok := strings.CutPrefix(env, "container="); ok && ct != "" {
+			return ct
+		}
+	}
+	return ""
+}
+
 func parseCgroupForContainer() string {
 	data, err := os.ReadFile("/proc/self/cgroup")
 	if err != nil {
 		return ""
 	}
-	lower := strings.ToLower(string(data))
+	return containerFromCgroup(string(data))
+}
+
+// containerFromCgroup 根据 /proc/self/cgroup 的内容判断容器运行时
+func containerFromCgroup(data string) string {
+	lower := strings.ToLower(data)
 
 	// Precompile (once) regex patterns for common container runtimes.
 	// Patterns target leaf elements referencing container IDs instead of any occurrence of runtime name to reduce false positives.
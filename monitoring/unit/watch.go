@@ -0,0 +1,284 @@
+package monitoring
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// WatchTarget 监视列表中的一项
+type WatchTarget struct {
+	// Kind name（进程名）、regex（匹配命令行）、pidfile 或 unit（systemd 单元）
+	Kind  string
+	Value string
+
+	re *regexp.Regexp
+}
+
+// WatchStatus 监视项当前匹配到的进程及其资源占用
+type WatchStatus struct {
+	Target WatchTarget
+	PIDs   []int32
+	// CPU 与上一次采集之间的 CPU 使用率之和，100 表示占满一个核心
+	CPU float64
+	// RSS 常驻内存之和，单位字节
+	RSS uint64
+	Err error
+}
+
+// ParseWatchList 解析 "name:nginx;regex:^postgres;pidfile:/run/app.pid;unit:redis.service"，
+// 未写类型的项视为进程名。无法解析的项会被跳过并返回错误。
+func ParseWatchList(s string) ([]WatchTarget, error) {
+	var targets []WatchTarget
+	var errs []error
+	seen := map[string]struct{}{}
+	for _, item := range strings.Split(s, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		t := WatchTarget{Kind: "name", Value: item}
+		if kind, value, ok := strings.Cut(item, ":"); ok {
+			switch kind {
+			case "name", "regex", "pidfile", "unit":
+				t.Kind, t.Value = kind, strings.TrimSpace(value)
+			}
+		}
+		if t.Value == "" {
+			errs = append(errs, fmt.Errorf("empty watch entry %q", item))
+			continue
+		}
+		if t.Kind == "regex" {
+			re, err := regexp.Compile(t.Value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid watch regex %q: %w", t.Value, err))
+				continue
+			}
+			t.re = re
+		}
+		if _, dup := seen[t.Key()]; dup {
+			continue
+		}
+		seen[t.Key()] = struct{}{}
+		targets = append(targets, t)
+	}
+	return targets, errors.Join(errs...)
+}
+
+// Key 监视项的唯一标识，即 kind:value
+func (t WatchTarget) Key() string {
+	return t.Kind + ":" + t.Value
+}
+
+var watchTracker processCPUTracker
+
+// Watch 查找各监视项对应的进程。只有存在 name/regex 项时才遍历全部进程。
+func Watch(ctx context.Context, targets []WatchTarget) []WatchStatus {
+	statuses := make([]WatchStatus, len(targets))
+	scan := false
+	for i, t := range targets {
+		statuses[i].Target = t
+		switch t.Kind {
+		case "pidfile":
+			statuses[i].PIDs, statuses[i].Err = pidfilePIDs(ctx, t.Value)
+		case "unit":
+			statuses[i].PIDs, statuses[i].Err = unitPIDs(t.Value)
+		default:
+			scan = true
+		}
+	}
+	if scan {
+		self := int32(os.Getpid())
+		procs, err := process.ProcessesWithContext(ctx)
+		for _, p := range procs {
+			name, cmdline := "", ""
+			var matched []int
+			for i, t := range targets {
+				switch t.Kind {
+				case "name":
+					if name == "" {
+						name, _ = p.NameWithContext(ctx)
+					}
+					if name == t.Value {
+						matched = append(matched, i)
+					}
+				case "regex":
+					if cmdline == "" {
+						cmdline, _ = p.CmdlineWithContext(ctx)
+					}
+					if t.re.MatchString(cmdline) {
+						matched = append(matched, i)
+					}
+				}
+			}
+			if len(matched) == 0 || isAgentProcess(ctx, p.Pid, self) {
+				continue
+			}
+			for _, i := range matched {
+				statuses[i].PIDs = append(statuses[i].PIDs, p.Pid)
+			}
+		}
+		if err != nil {
+			for i, t := range targets {
+				if t.Kind == "name" || t.Kind == "regex" {
+					statuses[i].Err = err
+				}
+			}
+		}
+	}
+	watchUsage(ctx, statuses)
+	return statuses
+}
+
+// isAgentProcess 判断进程是否为 agent 自身或其子孙进程。
+// 远程执行的命令与钩子的命令行常包含被监视的名称，不应计入监视项。
+func isAgentProcess(ctx context.Context, pid, self int32) bool {
+	return descendsFrom(pid, self, func(pid int32) (int32, error) {
+		p, err := process.NewProcessWithContext(ctx, pid)
+		if err != nil {
+			return 0, err
+		}
+		return p.PpidWithContext(ctx)
+	})
+}
+
+// descendsFrom 沿 ppid 向上查找 pid 是否为 ancestor 或其子孙进程。
+// 先与 ancestor 比较再在 PID 1 处停止，agent 在容器中通常以 PID 1 运行。
+func descendsFrom(pid, ancestor int32, ppid func(int32) (int32, error)) bool {
+	// 限制向上查找的层数，避免进程号被复用时形成环
+	for depth := 0; depth < 64; depth++ {
+		if pid == ancestor {
+			return true
+		}
+		if pid <= 1 {
+			return false
+		}
+		parent, err := ppid(pid)
+		if err != nil {
+			return false
+		}
+		pid = parent
+	}
+	return false
+}
+
+// watchUsage 计算各监视项的 CPU 使用率与 RSS，CPU 使用率按与上一次调用之间的区间计算
+func watchUsage(ctx context.Context, statuses []WatchStatus) {
+	now := time.Now()
+	watchTracker.mu.Lock()
+	defer watchTracker.mu.Unlock()
+	last := watchTracker.last
+	current := map[int32]processCPUSample{}
+	for i := range statuses {
+		for _, pid := range statuses[i].PIDs {
+			p, err := process.NewProcessWithContext(ctx, pid)
+			if err != nil {
+				continue
+			}
+			if times, err := p.TimesWithContext(ctx); err == nil {
+				total := times.User + times.System
+				current[pid] = processCPUSample{total: total, at: now}
+				if prev, seen := last[pid]; seen && total >= prev.total {
+					if elapsed := now.Sub(prev.at).Seconds(); elapsed > 0 {
+						statuses[i].CPU += (total - prev.total) / elapsed * 100
+					}
+				}
+			}
+			if mem, err := p.MemoryInfoWithContext(ctx); err == nil {
+				statuses[i].RSS += mem.RSS
+			}
+		}
+	}
+	watchTracker.last = current
+}
+
+// pidfilePIDs 读取 pidfile，进程不存在时返回空结果
+func pidfilePIDs(ctx context.Context, path string) ([]int32, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// 服务停止时通常会删除 pidfile
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pid, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pidfile %s: %q", path, strings.TrimSpace(string(data)))
+	}
+	exists, err := process.PidExistsWithContext(ctx, int32(pid))
+	if err != nil || !exists {
+		return nil, err
+	}
+	return []int32{int32(pid)}, nil
+}
+
+// unitPIDs 从 systemd 为单元创建的 cgroup 读取其中的全部进程
+func unitPIDs(unit string) ([]int32, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("watching systemd units is only supported on Linux")
+	}
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+	dir := unitCgroupDir(filepath.Join(sysfsRoot(), "fs", "cgroup"), unit)
+	if dir == "" {
+		// 单元未运行时 systemd 会删除其 cgroup
+		return nil, nil
+	}
+	seen := map[int32]struct{}{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != "cgroup.procs" {
+			return err
+		}
+		return readCgroupProcs(path, seen)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	pids := make([]int32, 0, len(seen))
+	for pid := range seen {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids, nil
+}
+
+// unitCgroupDir 在 cgroup v2（或混合模式的 unified/systemd 层级）中查找单元所在目录
+func unitCgroupDir(cgroupRoot, unit string) string {
+	for _, base := range []string{cgroupRoot, filepath.Join(cgroupRoot, "unified"), filepath.Join(cgroupRoot, "systemd")} {
+		for _, pattern := range []string{filepath.Join(base, "system.slice", unit), filepath.Join(base, "*.slice", unit)} {
+			if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+				return matches[0]
+			}
+		}
+	}
+	return ""
+}
+
+func readCgroupProcs(path string, pids map[int32]struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if pid, err := strconv.ParseInt(strings.TrimSpace(scanner.Text()), 10, 32); err == nil {
+			pids[int32(pid)] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
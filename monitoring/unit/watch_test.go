@@ -0,0 +1,166 @@
+package monitoring
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+func TestParseWatchList(t *testing.T) {
+	targets, err := ParseWatchList("nginx; regex:^postgres: ;pidfile:/run/app.pid;unit:redis;name:nginx;regex:(bad;pidfile:")
+	if err == nil {
+		t.Error("expected errors for the invalid entries")
+	}
+	want := []string{"name:nginx", "regex:^postgres:", "pidfile:/run/app.pid", "unit:redis"}
+	if len(targets) != len(want) {
+		t.Fatalf("targets = %+v", targets)
+	}
+	for i, key := range want {
+		if targets[i].Key() != key {
+			t.Errorf("target %d = %s, want %s", i, targets[i].Key(), key)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	self, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := self.Name()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pidfile := filepath.Join(t.TempDir(), "self.pid")
+	if err := os.WriteFile(pidfile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := ParseWatchList("name:" + name + ";regex:-test\\.;pidfile:" + pidfile + ";pidfile:" + pidfile + ".missing;name:komari-no-such-process")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := Watch(context.Background(), targets)
+	// name/regex 不匹配 agent 自身，pidfile 按文件内容
+	for i, st := range statuses[:3] {
+		found := false
+		for _, pid := range st.PIDs {
+			found = found || pid == int32(os.Getpid())
+		}
+		if st.Err != nil || found != (st.Target.Kind == "pidfile") {
+			t.Errorf("status %d (%s) = %+v", i, st.Target.Key(), st)
+		}
+	}
+	if statuses[2].RSS == 0 {
+		t.Errorf("pidfile status has no RSS: %+v", statuses[2])
+	}
+	for _, st := range statuses[3:] {
+		if len(st.PIDs) != 0 || st.Err != nil {
+			t.Errorf("%s = %+v, want stopped", st.Target.Key(), st)
+		}
+	}
+}
+
+func TestWatchExcludesAgentChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh and sleep")
+	}
+	// 子进程，例如远程执行的命令
+	child := exec.Command("sleep", "31.235")
+	if err := child.Start(); err != nil {
+		t.Skip(err)
+	}
+	defer func() {
+		child.Process.Kill()
+		child.Wait()
+	}()
+	// sh 退出后 sleep 不再是 agent 的子孙进程
+	out, err := exec.Command("sh", "-c", "sleep 31.234 >/dev/null 2>&1 & echo $!").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphan, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := os.FindProcess(orphan); err == nil {
+		defer p.Kill()
+	}
+
+	targets, err := ParseWatchList(`regex:^sleep 31\.23[45]$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := Watch(context.Background(), targets)
+	if pids := statuses[0].PIDs; len(pids) != 1 || pids[0] != int32(orphan) {
+		t.Errorf("PIDs = %v, want only %d (child %d excluded)", pids, orphan, child.Process.Pid)
+	}
+}
+
+func TestDescendsFrom(t *testing.T) {
+	// 1 为容器中以 PID 1 运行的 agent，20 为其远程执行的 sh，21 为 sh 启动的命令
+	parents := map[int32]int32{1: 0, 20: 1, 21: 20, 30: 1, 40: 7, 7: 1}
+	ppid := func(pid int32) (int32, error) {
+		parent, ok := parents[pid]
+		if !ok {
+			return 0, os.ErrNotExist
+		}
+		return parent, nil
+	}
+	for _, tc := range []struct {
+		pid, self int32
+		want      bool
+	}{
+		{1, 1, true},
+		{20, 1, true},
+		{21, 1, true},
+		{21, 20, true},
+		{30, 20, false},
+		{40, 7, true},
+		{40, 20, false},
+		{99, 1, false},
+	} {
+		if got := descendsFrom(tc.pid, tc.self, ppid); got != tc.want {
+			t.Errorf("descendsFrom(%d, %d) = %v, want %v", tc.pid, tc.self, got, tc.want)
+		}
+	}
+}
+
+func TestUnitPIDs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("systemd units are Linux only")
+	}
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"fs/cgroup/cgroup.controllers":                            "cpu memory",
+		"fs/cgroup/system.slice/nginx.service/cgroup.procs":       "120\n121",
+		"fs/cgroup/system.slice/nginx.service/sub/cgroup.procs":   "130",
+		"fs/cgroup/user.slice/backup.timer/cgroup.procs":          "",
+		"fs/cgroup/unified/system.slice/old.service/cgroup.procs": "7",
+	})
+	old := flags.Current().SysfsRoot
+	flags.Update(func(c *flags.Config) { c.SysfsRoot = root })
+	defer flags.Update(func(c *flags.Config) { c.SysfsRoot = old })
+
+	pids, err := unitPIDs("nginx")
+	if err != nil || len(pids) != 3 || pids[0] != 120 || pids[2] != 130 {
+		t.Errorf("nginx = %v, %v", pids, err)
+	}
+	if pids, err := unitPIDs("backup.timer"); err != nil || len(pids) != 0 {
+		t.Errorf("backup.timer = %v, %v", pids, err)
+	}
+	if pids, err := unitPIDs("old.service"); err != nil || len(pids) != 1 {
+		t.Errorf("old.service in the hybrid hierarchy = %v, %v", pids, err)
+	}
+	if pids, err := unitPIDs("stopped.service"); err != nil || len(pids) != 0 {
+		t.Errorf("stopped.service = %v, %v", pids, err)
+	}
+}
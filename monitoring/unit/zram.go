@@ -0,0 +1,134 @@
+package monitoring
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ZramInfo 单个 zram 设备的压缩情况，单位字节
+type ZramInfo struct {
+	Name string
+	// OrigDataSize 压缩前的数据量
+	OrigDataSize uint64
+	// ComprDataSize 压缩后的数据量
+	ComprDataSize uint64
+	// MemUsedTotal 实际占用的内存，含分配器开销
+	MemUsedTotal uint64
+	// Ratio OrigDataSize / ComprDataSize，没有数据时为 0
+	Ratio float64
+}
+
+// ZswapInfo zswap 压缩池情况，单位字节
+type ZswapInfo struct {
+	// Stored 存入 zswap 的页面压缩前大小
+	Stored uint64
+	// PoolSize 压缩池占用的内存
+	PoolSize uint64
+	// Ratio Stored / PoolSize，没有数据时为 0
+	Ratio float64
+}
+
+// readZram 读取 <sysfs>/block/zram*/mm_stat
+func readZram(sysfs string) []ZramInfo {
+	stats, _ := filepath.Glob(filepath.Join(sysfs, "block", "zram*", "mm_stat"))
+	sort.Strings(stats)
+	var devices []ZramInfo
+	for _, path := range stats {
+		// 字段依次为 orig_data_size compr_data_size mem_used_total mem_limit ...
+		fields := strings.Fields(readSysfsString(path))
+		if len(fields) < 3 {
+			continue
+		}
+		values := make([]uint64, 3)
+		valid := true
+		for i := range values {
+			v, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			values[i] = v
+		}
+		if !valid {
+			continue
+		}
+		devices = append(devices, ZramInfo{
+			Name:          filepath.Base(filepath.Dir(path)),
+			OrigDataSize:  values[0],
+			ComprDataSize: values[1],
+			MemUsedTotal:  values[2],
+			Ratio:         ratio(values[0], values[1]),
+		})
+	}
+	return devices
+}
+
+// readZswap 优先使用 /proc/meminfo 的 Zswap/Zswapped（5.19+），
+// 旧内核退回 debugfs 中的 pool_total_size/stored_pages，未启用 zswap 时返回 nil。
+func readZswap(meminfo, sysfs string) *ZswapInfo {
+	if readSysfsString(filepath.Join(sysfs, "module", "zswap", "parameters", "enabled")) != "Y" {
+		return nil
+	}
+	values := meminfoValues(meminfo, "Zswap", "Zswapped")
+	pool, okPool := values["Zswap"]
+	stored, okStored := values["Zswapped"]
+	if !okPool || !okStored {
+		debugfs := filepath.Join(sysfs, "kernel", "debug", "zswap")
+		var err1, err2 error
+		pool, err1 = strconv.ParseUint(readSysfsString(filepath.Join(debugfs, "pool_total_size")), 10, 64)
+		pages, err2 := strconv.ParseUint(readSysfsString(filepath.Join(debugfs, "stored_pages")), 10, 64)
+		if err1 != nil || err2 != nil {
+			return &ZswapInfo{}
+		}
+		stored = pages * uint64(os.Getpagesize())
+	}
+	return &ZswapInfo{Stored: stored, PoolSize: pool, Ratio: ratio(stored, pool)}
+}
+
+// meminfoValues 读取 /proc/meminfo 中指定项，单位换算为字节
+func meminfoValues(path string, keys ...string) map[string]uint64 {
+	values := map[string]uint64{}
+	f, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+	wanted := map[string]struct{}{}
+	for _, k := range keys {
+		wanted[k] = struct{}{}
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		if _, ok := wanted[key]; !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		values[key] = v
+	}
+	return values
+}
+
+func ratio(orig, compressed uint64) float64 {
+	if compressed == 0 {
+		return 0
+	}
+	return float64(orig) / float64(compressed)
+}
@@ -0,0 +1,58 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadZram(t *testing.T) {
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"block/zram0/mm_stat": "  419430400  104857600  110100480        0  120000000        0        0        0        0",
+		// 未使用的设备
+		"block/zram1/mm_stat": "0 0 0 0 0 0 0 0 0",
+		"block/vda/stat":      "1 2 3",
+	})
+	devices := readZram(root)
+	if len(devices) != 2 {
+		t.Fatalf("devices = %+v", devices)
+	}
+	want := ZramInfo{Name: "zram0", OrigDataSize: 400 << 20, ComprDataSize: 100 << 20, MemUsedTotal: 105 << 20, Ratio: 4}
+	if devices[0] != want {
+		t.Errorf("zram0 = %+v, want %+v", devices[0], want)
+	}
+	if devices[1].Name != "zram1" || devices[1].Ratio != 0 {
+		t.Errorf("zram1 = %+v", devices[1])
+	}
+}
+
+func TestReadZswap(t *testing.T) {
+	root := t.TempDir()
+	meminfo := filepath.Join(root, "meminfo")
+	writeSysfs(t, root, map[string]string{
+		"meminfo": "MemTotal:        8039896 kB\nZswap:             10240 kB\nZswapped:          30720 kB\n",
+	})
+
+	if z := readZswap(meminfo, root); z != nil {
+		t.Errorf("zswap disabled but got %+v", z)
+	}
+
+	writeSysfs(t, root, map[string]string{"module/zswap/parameters/enabled": "Y"})
+	z := readZswap(meminfo, root)
+	if z == nil || *z != (ZswapInfo{Stored: 30 << 20, PoolSize: 10 << 20, Ratio: 3}) {
+		t.Errorf("zswap from meminfo = %+v", z)
+	}
+
+	// 旧内核的 meminfo 没有 Zswap 项，改用 debugfs
+	writeSysfs(t, root, map[string]string{
+		"meminfo":                            "MemTotal:        8039896 kB\n",
+		"kernel/debug/zswap/pool_total_size": "8192",
+		"kernel/debug/zswap/stored_pages":    "4",
+	})
+	z = readZswap(meminfo, root)
+	want := uint64(4 * os.Getpagesize())
+	if z == nil || z.Stored != want || z.PoolSize != 8192 || z.Ratio != float64(want)/8192 {
+		t.Errorf("zswap from debugfs = %+v", z)
+	}
+}
@@ -0,0 +1,169 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+// watchInterval 监视列表的默认检查间隔
+const watchInterval = 5 * time.Second
+
+// WatchEvent 监视项状态变化时推送的事件
+type WatchEvent struct {
+	// Type 固定为 watch_state
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	// Status running、stopped 或 restarted（两次检查之间进程已全部被新进程替换）
+	Status    string `json:"status"`
+	Previous  string `json:"previous"`
+	Instances int    `json:"instances"`
+}
+
+// watchEntry 单个监视项上一次的状态
+type watchEntry struct {
+	status   string
+	pids     map[int32]struct{}
+	restarts int
+	// started 曾经处于运行状态，之后的启动才计为重启
+	started bool
+}
+
+// watchState 各监视项的状态，重启次数从 agent 启动时开始计算
+type watchState struct {
+	mu      sync.Mutex
+	entries map[string]*watchEntry
+}
+
+var watchTracker watchState
+
+// update 根据本次检查结果更新状态，返回报告与状态变化事件。
+// 检查出错的项状态为 unknown，不触发事件，也不覆盖上一次的状态。
+func (s *watchState) update(statuses []monitoring.WatchStatus, now time.Time) ([]WatchReport, []WatchEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make(map[string]*watchEntry, len(statuses))
+	reports := make([]WatchReport, 0, len(statuses))
+	var events []WatchEvent
+	for _, st := range statuses {
+		key := st.Target.Key()
+		entry, known := s.entries[key]
+		if !known {
+			entry = &watchEntry{}
+		}
+		entries[key] = entry
+		report := WatchReport{
+			Name:      st.Target.Value,
+			Kind:      st.Target.Kind,
+			Status:    "unknown",
+			Instances: len(st.PIDs),
+			CPU:       st.CPU,
+			RSS:       st.RSS,
+		}
+		if st.Err != nil && len(st.PIDs) == 0 {
+			report.Restarts = entry.restarts
+			reports = append(reports, report)
+			continue
+		}
+
+		status := "stopped"
+		if len(st.PIDs) > 0 {
+			status = "running"
+		}
+		pids := make(map[int32]struct{}, len(st.PIDs))
+		replaced := len(entry.pids) > 0
+		for _, pid := range st.PIDs {
+			pids[pid] = struct{}{}
+			if _, ok := entry.pids[pid]; ok {
+				replaced = false
+			}
+		}
+
+		event := ""
+		switch {
+		case !known || entry.status == "":
+		case entry.status != status:
+			event = status
+			if status == "running" && entry.started {
+				entry.restarts++
+			}
+		case status == "running" && replaced:
+			event = "restarted"
+			entry.restarts++
+		}
+		if event != "" {
+			events = append(events, WatchEvent{
+				Type:      "watch_state",
+				Timestamp: now,
+				Name:      st.Target.Value,
+				Kind:      st.Target.Kind,
+				Status:    event,
+				Previous:  entry.status,
+				Instances: len(st.PIDs),
+			})
+		}
+		entry.status, entry.pids = status, pids
+		entry.started = entry.started || status == "running"
+
+		report.Status = status
+		report.Restarts = entry.restarts
+		reports = append(reports, report)
+	}
+	s.entries = entries
+	return reports, events
+}
+
+// watchMetrics 各监视项的状态
+type watchMetrics []WatchReport
+
+func (m watchMetrics) Apply(r *Report) { r.Watch = m }
+
+var (
+	watchMu      sync.Mutex
+	watchSource  string
+	watchTargets []monitoring.WatchTarget
+	watchErr     error
+	watchLoaded  bool
+)
+
+// currentWatchTargets 解析 --watch，参数未变化时复用上次结果
+func currentWatchTargets() ([]monitoring.WatchTarget, error) {
+	cfg := flags.Current()
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	if !watchLoaded || watchSource != cfg.Watch {
+		watchSource, watchLoaded = cfg.Watch, true
+		watchTargets, watchErr = monitoring.ParseWatchList(cfg.Watch)
+	}
+	return watchTargets, watchErr
+}
+
+type watchCollector struct{}
+
+func (watchCollector) Name() string            { return "watch" }
+func (watchCollector) Enabled() bool           { return flags.Current().Watch != "" }
+func (watchCollector) Interval() time.Duration { return watchInterval }
+func (watchCollector) Collect(ctx context.Context) (Metrics, error) {
+	targets, err := currentWatchTargets()
+	errs := []error{err}
+	statuses := monitoring.Watch(ctx, targets)
+	for _, st := range statuses {
+		if st.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", st.Target.Key(), st.Err))
+		}
+	}
+	reports, events := watchTracker.update(statuses, time.Now())
+	for _, ev := range events {
+		log.Printf("Watched %s %q is now %s (was %s, %d instances)", ev.Kind, ev.Name, ev.Status, ev.Previous, ev.Instances)
+		emitEvent(ev)
+	}
+	return watchMetrics(reports), errors.Join(errs...)
+}
@@ -0,0 +1,64 @@
+package monitoring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+func TestWatchStateTransitions(t *testing.T) {
+	targets, err := monitoring.ParseWatchList("nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &watchState{}
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	step := func(pids []int32, err error) ([]WatchReport, []WatchEvent) {
+		now = now.Add(5 * time.Second)
+		return state.update([]monitoring.WatchStatus{{Target: targets[0], PIDs: pids, Err: err}}, now)
+	}
+	status := func(events []WatchEvent) string {
+		if len(events) != 1 {
+			return ""
+		}
+		return events[0].Previous + "->" + events[0].Status
+	}
+
+	// 首次检查只记录状态
+	if reports, events := step([]int32{10, 11}, nil); len(events) != 0 || reports[0].Status != "running" || reports[0].Instances != 2 {
+		t.Fatalf("first check = %+v, %+v", reports, events)
+	}
+	if _, events := step([]int32{11}, nil); len(events) != 0 {
+		t.Errorf("worker exit reported as %+v", events)
+	}
+	if _, events := step(nil, nil); status(events) != "running->stopped" {
+		t.Errorf("stop = %+v", events)
+	}
+	// 检查出错时不改变状态
+	if reports, events := step(nil, errors.New("permission denied")); len(events) != 0 || reports[0].Status != "unknown" {
+		t.Errorf("error = %+v, %+v", reports, events)
+	}
+	if reports, events := step([]int32{20}, nil); status(events) != "stopped->running" || reports[0].Restarts != 1 {
+		t.Errorf("start = %+v, %+v", reports, events)
+	}
+	// 两次检查之间进程被全部替换
+	if reports, events := step([]int32{30}, nil); status(events) != "running->restarted" || reports[0].Restarts != 2 {
+		t.Errorf("restart = %+v, %+v", reports, events)
+	}
+	if _, events := step([]int32{30}, nil); len(events) != 0 {
+		t.Errorf("steady state = %+v", events)
+	}
+}
+
+func TestWatchStateFirstStartIsNotRestart(t *testing.T) {
+	targets, _ := monitoring.ParseWatchList("pidfile:/run/app.pid")
+	state := &watchState{}
+	now := time.Now()
+	state.update([]monitoring.WatchStatus{{Target: targets[0]}}, now)
+	reports, events := state.update([]monitoring.WatchStatus{{Target: targets[0], PIDs: []int32{1}}}, now.Add(time.Second))
+	if len(events) != 1 || events[0].Status != "running" || events[0].Type != "watch_state" || reports[0].Restarts != 0 {
+		t.Errorf("first start = %+v, %+v", reports, events)
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// ReconnectPolicy 决定第 attempt 次（从 1 开始）连续失败后到下一次重连前的等待时间
+type ReconnectPolicy interface {
+	Name() string
+	Delay(attempt int) time.Duration
+}
+
+// FixedPolicy 每次重连前等待固定时间，并加入 ±Jitter 比例的随机抖动，
+// 避免大量 agent 同时重启后同步重连
+type FixedPolicy struct {
+	Interval time.Duration
+	Jitter   float64
+}
+
+func (p FixedPolicy) Name() string { return "fixed" }
+
+func (p FixedPolicy) Delay(attempt int) time.Duration {
+	return jitter(p.Interval, p.Jitter)
+}
+
+// ExponentialPolicy 指数退避，等待时间每次翻倍直到 Max，并加入 ±Jitter 比例的随机抖动
+type ExponentialPolicy struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter float64
+}
+
+func (p ExponentialPolicy) Name() string { return "exponential" }
+
+func (p ExponentialPolicy) Delay(attempt int) time.Duration {
+	delay := p.Base
+	for i := 1; i < attempt && delay < p.Max; i++ {
+		delay *= 2
+	}
+	if p.Max > 0 && delay > p.Max {
+		delay = p.Max
+	}
+	delay = jitter(delay, p.Jitter)
+	if p.Max > 0 && delay > p.Max {
+		delay = p.Max
+	}
+	return delay
+}
+
+// jitter 在 delay 上加入 ±ratio 比例的随机抖动，结果不小于 0
+func jitter(delay time.Duration, ratio float64) time.Duration {
+	if ratio > 0 {
+		delta := (rand.Float64()*2 - 1) * ratio * float64(delay)
+		delay += time.Duration(delta)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// NewReconnectPolicy 根据命令行参数创建重连策略
+func NewReconnectPolicy() ReconnectPolicy {
+	cfg := flags.Current()
+	base := time.Duration(cfg.ReconnectInterval) * time.Second
+	switch cfg.ReconnectPolicy {
+	case "fixed":
+		return FixedPolicy{Interval: base, Jitter: cfg.ReconnectJitter}
+	case "exponential":
+	default:
+		log.Printf("Unknown reconnect policy %q, using exponential", cfg.ReconnectPolicy)
+	}
+	return ExponentialPolicy{
+		Base:   base,
+		Max:    time.Duration(cfg.ReconnectMaxInterval) * time.Second,
+		Jitter: cfg.ReconnectJitter,
+	}
+}
+
+// HandshakeError WebSocket 握手被服务端以非 101 状态码拒绝
+type HandshakeError struct {
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration
+}
+
+func (e *HandshakeError) Error() string {
+	return e.Status
+}
+
+// newHandshakeError 从握手响应中提取状态码与 Retry-After
+func newHandshakeError(resp *http.Response) *HandshakeError {
+	return &HandshakeError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数与 HTTP 日期两种格式
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// reconnectBackoff 记录连续连接失败的次数，在多次 EstablishWebSocketConnection 调用间保留
+type reconnectBackoff struct {
+	attempt int
+	next    time.Time
+}
+
+var backoffState reconnectBackoff
+
+// ready 是否已到下一次重连时间
+func (b *reconnectBackoff) ready(now time.Time) bool {
+	return !now.Before(b.next)
+}
+
+// failure 记录一次失败，返回下一次重连前的等待时间
+func (b *reconnectBackoff) failure(policy ReconnectPolicy, err error, now time.Time) time.Duration {
+	b.attempt++
+	var retryAfter time.Duration
+	var he *HandshakeError
+	if errors.As(err, &he) {
+		retryAfter = he.RetryAfter
+		// 服务端过载但未给出 Retry-After 时加快退避
+		if retryAfter == 0 && (he.StatusCode == http.StatusTooManyRequests || he.StatusCode == http.StatusServiceUnavailable) {
+			b.attempt++
+		}
+	}
+	delay := policy.Delay(b.attempt)
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	b.next = now.Add(delay)
+	return delay
+}
+
+// remaining 距离下一次重连还需等待的时间
+func (b *reconnectBackoff) remaining(now time.Time) time.Duration {
+	if d := b.next.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// WaitReconnectBackoff 等待到下一次允许重连的时间。
+// 外层循环在重新上传基础信息与建立连接前调用，使多轮重试之间同样按策略退避。
+func WaitReconnectBackoff() {
+	if d := backoffState.remaining(time.Now()); d > 0 {
+		log.Printf("Waiting %s before reconnecting (%s)", d.Round(time.Millisecond), backoffState.String())
+		time.Sleep(d)
+	}
+}
+
+// success 连接成功后重置退避状态，返回此前连续失败的次数
+func (b *reconnectBackoff) success() int {
+	attempts := b.attempt
+	b.attempt = 0
+	b.next = time.Time{}
+	return attempts
+}
+
+// String 输出当前退避状态，用于日志
+func (b *reconnectBackoff) String() string {
+	return fmt.Sprintf("attempt %d, next retry at %s", b.attempt, b.next.Format(time.RFC3339))
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExponentialPolicyDelay(t *testing.T) {
+	policy := ExponentialPolicy{Base: time.Second, Max: 30 * time.Second}
+	want := []time.Duration{1, 2, 4, 8, 16, 30, 30}
+	for i, w := range want {
+		if got := policy.Delay(i + 1); got != w*time.Second {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, w*time.Second)
+		}
+	}
+}
+
+func TestExponentialPolicyJitter(t *testing.T) {
+	policy := ExponentialPolicy{Base: 10 * time.Second, Max: time.Minute, Jitter: 0.5}
+	for i := 0; i < 1000; i++ {
+		got := policy.Delay(1)
+		if got < 5*time.Second || got > 15*time.Second {
+			t.Fatalf("Delay(1) = %v, want within [5s, 15s]", got)
+		}
+		if got := policy.Delay(10); got > time.Minute {
+			t.Fatalf("Delay(10) = %v, exceeds max", got)
+		}
+	}
+}
+
+func TestFixedPolicyJitter(t *testing.T) {
+	if got := (FixedPolicy{Interval: 5 * time.Second}).Delay(3); got != 5*time.Second {
+		t.Errorf("Delay without jitter = %v, want 5s", got)
+	}
+	policy := FixedPolicy{Interval: 10 * time.Second, Jitter: 0.2}
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 1000; i++ {
+		got := policy.Delay(1)
+		if got < 8*time.Second || got > 12*time.Second {
+			t.Fatalf("Delay(1) = %v, want within [8s, 12s]", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("jitter did not vary the delay")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"Wed, 01 Jan 2025 00:00:30 GMT", 30 * time.Second},
+		{"Tue, 31 Dec 2024 23:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	policy := ExponentialPolicy{Base: time.Second, Max: time.Minute}
+	now := time.Now()
+	var b reconnectBackoff
+
+	if !b.ready(now) {
+		t.Fatal("fresh backoff should be ready")
+	}
+	if d := b.failure(policy, errors.New("dial failed"), now); d != time.Second {
+		t.Errorf("first failure delay = %v, want 1s", d)
+	}
+	if b.ready(now) || !b.ready(now.Add(time.Second)) {
+		t.Error("ready() does not honour the delay")
+	}
+	if d := b.remaining(now.Add(300 * time.Millisecond)); d != 700*time.Millisecond {
+		t.Errorf("remaining = %v, want 700ms", d)
+	}
+	if d := b.remaining(now.Add(2 * time.Second)); d != 0 {
+		t.Errorf("remaining after the delay = %v, want 0", d)
+	}
+
+	// Retry-After 大于策略给出的等待时间时以服务端为准
+	err := &HandshakeError{StatusCode: http.StatusServiceUnavailable, RetryAfter: 45 * time.Second}
+	if d := b.failure(policy, err, now); d != 45*time.Second {
+		t.Errorf("Retry-After delay = %v, want 45s", d)
+	}
+
+	// 429 未带 Retry-After 时跳过一级退避
+	before := b.attempt
+	b.failure(policy, &HandshakeError{StatusCode: http.StatusTooManyRequests}, now)
+	if b.attempt != before+2 {
+		t.Errorf("attempt = %d after 429, want %d", b.attempt, before+2)
+	}
+
+	if attempts := b.success(); attempts != before+2 {
+		t.Errorf("success() = %d, want %d", attempts, before+2)
+	}
+	if b.attempt != 0 || !b.ready(now) {
+		t.Error("success() did not reset the backoff")
+	}
+}
@@ -10,16 +10,26 @@ import (
 	"time"
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
+	report "github.com/komari-monitor/komari-agent/monitoring"
 	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
 	"github.com/komari-monitor/komari-agent/update"
 )
 
 func DoUploadBasicInfoWorks() {
-	ticker := time.NewTicker(time.Duration(flags.InfoReportInterval) * time.Minute)
-	for range ticker.C {
-		err := uploadBasicInfo()
-		if err != nil {
-			log.Println("Error uploading basic info:", err)
+	ticker := time.NewTicker(time.Duration(flags.Current().InfoReportInterval) * time.Minute)
+	for {
+		select {
+		case <-ticker.C:
+			err := uploadBasicInfo()
+			if err != nil {
+				log.Println("Error uploading basic info:", err)
+			}
+		case reconnect := <-infoConfigChanged:
+			ticker.Reset(time.Duration(flags.Current().InfoReportInterval) * time.Minute)
+			// 连接参数变化后立即向新的服务端上报基础信息
+			if reconnect {
+				UpdateBasicInfo()
+			}
 		}
 	}
 }
@@ -31,6 +41,26 @@ func UpdateBasicInfo() {
 		log.Println("Basic info uploaded successfully")
 	}
 }
+
+// listeningPorts 获取失败时只记录日志，不影响基础信息上报
+func listeningPorts() []report.ListeningPortReport {
+	ports, err := monitoring.ListeningPorts()
+	if err != nil {
+		log.Println("Failed to get listening ports:", err)
+	}
+	result := make([]report.ListeningPortReport, 0, len(ports))
+	for _, p := range ports {
+		result = append(result, report.ListeningPortReport{
+			Protocol: p.Protocol,
+			Address:  p.Address,
+			Port:     p.Port,
+			PID:      p.PID,
+			Process:  p.Process,
+		})
+	}
+	return result
+}
+
 func uploadBasicInfo() error {
 	cpu := monitoring.Cpu()
 
@@ -38,28 +68,62 @@ func uploadBasicInfo() error {
 	kernelVersion := monitoring.KernelVersion()
 	ipv4, ipv6, _ := monitoring.GetIPAddress()
 
-	data := map[string]interface{}{
-		"cpu_name":       cpu.CPUName,
-		"cpu_cores":      cpu.CPUCores,
-		"arch":           cpu.CPUArchitecture,
-		"os":             osname,
-		"kernel_version": kernelVersion,
-		"ipv4":           ipv4,
-		"ipv6":           ipv6,
-		"mem_total":      monitoring.Ram().Total,
-		"swap_total":     monitoring.Swap().Total,
-		"disk_total":     monitoring.Disk().Total,
-		"gpu_name":       monitoring.GpuName(),
-		"virtualization": monitoring.Virtualized(),
-		"version":        update.CurrentVersion,
+	data := report.BasicInfo{
+		SchemaVersion:  report.SchemaVersion,
+		CPUName:        cpu.CPUName,
+		CPUCores:       cpu.CPUCores,
+		Arch:           cpu.CPUArchitecture,
+		OS:             osname,
+		KernelVersion:  kernelVersion,
+		IPv4:           ipv4,
+		IPv6:           ipv6,
+		MemTotal:       monitoring.Ram().Total,
+		SwapTotal:      monitoring.Swap().Total,
+		DiskTotal:      monitoring.Disk().Total,
+		GPUName:        monitoring.GpuName(),
+		Virtualization: monitoring.Virtualized(),
+		Version:        update.CurrentVersion,
+		ListeningPorts: listeningPorts(),
 	}
 
-	// 尝试上传完整数据
+	return uploadWithFallback(data)
+}
+
+// legacyBasicInfo <= 1.0.2 的服务端接受的基础信息，不含 schema_version、kernel_version 等新增字段
+type legacyBasicInfo struct {
+	CPUName        string `json:"cpu_name"`
+	CPUCores       int    `json:"cpu_cores"`
+	Arch           string `json:"arch"`
+	OS             string `json:"os"`
+	IPv4           string `json:"ipv4"`
+	IPv6           string `json:"ipv6"`
+	MemTotal       uint64 `json:"mem_total"`
+	SwapTotal      uint64 `json:"swap_total"`
+	DiskTotal      uint64 `json:"disk_total"`
+	GPUName        string `json:"gpu_name"`
+	Virtualization string `json:"virtualization"`
+	Version        string `json:"version"`
+}
+
+// uploadWithFallback 先上传完整数据，失败时按旧版服务端的字段重试
+func uploadWithFallback(data report.BasicInfo) error {
 	err := tryUploadData(data)
 	if err != nil {
 		// 兼容 <= 1.0.2
-		delete(data, "kernel_version")
-		err = tryUploadData(data)
+		err = tryUploadData(legacyBasicInfo{
+			CPUName:        data.CPUName,
+			CPUCores:       data.CPUCores,
+			Arch:           data.Arch,
+			OS:             data.OS,
+			IPv4:           data.IPv4,
+			IPv6:           data.IPv6,
+			MemTotal:       data.MemTotal,
+			SwapTotal:      data.SwapTotal,
+			DiskTotal:      data.DiskTotal,
+			GPUName:        data.GPUName,
+			Virtualization: data.Virtualization,
+			Version:        data.Version,
+		})
 		if err != nil {
 			return err
 		}
@@ -67,8 +131,9 @@ func uploadBasicInfo() error {
 	return nil
 }
 
-func tryUploadData(data map[string]interface{}) error {
-	endpoint := strings.TrimSuffix(flags.Endpoint, "/") + "/api/clients/uploadBasicInfo?token=" + flags.Token
+func tryUploadData(data interface{}) error {
+	cfg := flags.Current()
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/") + "/api/clients/uploadBasicInfo?token=" + cfg.Token
 	payload, err := json.Marshal(data)
 	if err != nil {
 		return err
@@ -79,14 +144,14 @@ func tryUploadData(data map[string]interface{}) error {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	
+
 	// 添加Cloudflare Access头部
-	if flags.CFAccessClientID != "" && flags.CFAccessClientSecret != "" {
-		req.Header.Set("CF-Access-Client-Id", flags.CFAccessClientID)
-		req.Header.Set("CF-Access-Client-Secret", flags.CFAccessClientSecret)
+	if cfg.CFAccessClientID != "" && cfg.CFAccessClientSecret != "" {
+		req.Header.Set("CF-Access-Client-Id", cfg.CFAccessClientID)
+		req.Header.Set("CF-Access-Client-Secret", cfg.CFAccessClientSecret)
 	}
 
-	client := &http.Client{}
+	client := HTTPClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
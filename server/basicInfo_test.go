@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	report "github.com/komari-monitor/komari-agent/monitoring"
+)
+
+func TestUploadBasicInfoLegacyFallback(t *testing.T) {
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		bodies = append(bodies, body)
+		// 模拟 <= 1.0.2 的服务端，拒绝不认识的字段
+		for key := range body {
+			if key == "schema_version" || key == "kernel_version" || key == "listening_ports" {
+				http.Error(w, "unknown field "+key, http.StatusBadRequest)
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	old := flags.Current().Endpoint
+	flags.Update(func(c *flags.Config) { c.Endpoint = srv.URL })
+	defer flags.Update(func(c *flags.Config) { c.Endpoint = old })
+
+	err := uploadWithFallback(report.BasicInfo{
+		SchemaVersion:  report.SchemaVersion,
+		CPUName:        "Test CPU",
+		CPUCores:       4,
+		KernelVersion:  "6.1.0",
+		Version:        "1.1.0",
+		ListeningPorts: []report.ListeningPortReport{{Protocol: "tcp", Port: 22}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("got %d uploads, want the full upload and one fallback", len(bodies))
+	}
+	var keys []string
+	for key := range bodies[1] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	want := "arch,cpu_cores,cpu_name,disk_total,gpu_name,ipv4,ipv6,mem_total,os,swap_total,version,virtualization"
+	if got := strings.Join(keys, ","); got != want {
+		t.Errorf("fallback fields = %s, want %s", got, want)
+	}
+	if bodies[1]["cpu_name"] != "Test CPU" || bodies[1]["version"] != "1.1.0" {
+		t.Errorf("fallback values = %v", bodies[1])
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"log"
+	"sync"
+
+	"github.com/komari-monitor/komari-agent/ws"
+)
+
+// maxPendingEvents 断线期间最多保留的事件数，超出时丢弃最早的事件
+const maxPendingEvents = 64
+
+// pendingEvents 尚未发送的事件。放在包级别，上报循环因重试次数用尽而返回时也不会丢失。
+var pendingEvents eventQueue
+
+type eventQueue struct {
+	mu     sync.Mutex
+	events []interface{}
+}
+
+// push 保存尚未发送的事件，等待连接恢复后发送
+func (q *eventQueue) push(ev interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.events = append(q.events, ev)
+	if len(q.events) > maxPendingEvents {
+		log.Printf("Dropping %d undelivered events", len(q.events)-maxPendingEvents)
+		q.events = q.events[len(q.events)-maxPendingEvents:]
+	}
+}
+
+// flush 按顺序发送事件，未能发送的部分留在队列中
+func (q *eventQueue) flush(send func(ev interface{}) error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, ev := range q.events {
+		if err := send(ev); err != nil {
+			q.events = q.events[i:]
+			return err
+		}
+	}
+	q.events = nil
+	return nil
+}
+
+// sendEvent 先发送积压的事件再发送 ev，失败时 ev 进入队列
+func sendEvent(conn *ws.SafeConn, ev interface{}) error {
+	pendingEvents.push(ev)
+	return pendingEvents.flush(conn.WriteValue)
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEventQueue(t *testing.T) {
+	var q eventQueue
+	// 断线期间超出上限时丢弃最早的事件
+	for i := 0; i < maxPendingEvents+6; i++ {
+		q.push(i)
+	}
+	var sent []interface{}
+	err := q.flush(func(ev interface{}) error {
+		if len(sent) == 10 {
+			return errors.New("broken pipe")
+		}
+		sent = append(sent, ev)
+		return nil
+	})
+	if err == nil || len(sent) != 10 || sent[0] != 6 {
+		t.Fatalf("first flush sent %v, %v", sent, err)
+	}
+	// 发送失败的事件留在队列中，连接恢复后继续发送
+	sent = nil
+	if err := q.flush(func(ev interface{}) error {
+		sent = append(sent, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != maxPendingEvents-10 || sent[0] != 16 || sent[len(sent)-1] != maxPendingEvents+5 {
+		t.Errorf("second flush sent %v", sent)
+	}
+	if err := q.flush(func(ev interface{}) error { return errors.New("unexpected") }); err != nil {
+		t.Error("queue should be empty after a successful flush")
+	}
+}
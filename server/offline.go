@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/buffer"
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/komari-monitor/komari-agent/monitoring"
+	"github.com/komari-monitor/komari-agent/ws"
+)
+
+var (
+	offlineMu     sync.Mutex
+	offlineBuffer *buffer.Ring
+	// offlineConfig 打开 offlineBuffer 时使用的配置，为 nil 表示尚未打开
+	offlineConfig *offlineBufferConfig
+	replaying     atomic.Bool
+)
+
+type offlineBufferConfig struct {
+	path    string
+	maxSize int
+	maxAge  int
+}
+
+// getOfflineBuffer 返回断线缓冲区，未启用或打开失败时返回 nil。
+// 重新加载配置后路径变化时重新打开，大小或保存时长变化时修改现有缓冲区的限制。
+func getOfflineBuffer() *buffer.Ring {
+	cfg := flags.Current()
+	want := offlineBufferConfig{path: cfg.OfflineBuffer, maxSize: cfg.OfflineBufferSize, maxAge: cfg.OfflineBufferMaxAge}
+	maxBytes := int64(want.maxSize) * 1024 * 1024
+	maxAge := time.Duration(want.maxAge) * time.Hour
+
+	offlineMu.Lock()
+	defer offlineMu.Unlock()
+	if offlineConfig != nil && *offlineConfig == want {
+		return offlineBuffer
+	}
+	if offlineConfig != nil && offlineConfig.path == want.path && offlineBuffer != nil {
+		offlineBuffer.SetLimits(maxBytes, maxAge)
+		offlineConfig = &want
+		return offlineBuffer
+	}
+	if offlineBuffer != nil && want.path == "" {
+		log.Println("Offline buffer disabled")
+	}
+	offlineBuffer, offlineConfig = nil, &want
+	if want.path == "" {
+		return nil
+	}
+	ring, err := buffer.Open(want.path, maxBytes, maxAge)
+	if err != nil {
+		log.Println("Failed to open offline buffer:", err)
+		return nil
+	}
+	offlineBuffer = ring
+	log.Println("Offline buffer enabled:", want.path)
+	return offlineBuffer
+}
+
+// bufferReport 在断线期间保存报告，等待连接恢复后补发
+func bufferReport(ts time.Time, report monitoring.Report) {
+	ring := getOfflineBuffer()
+	if ring == nil {
+		return
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Println("Failed to marshal report:", err)
+		return
+	}
+	if err := ring.Append(ts, data); err != nil {
+		log.Println("Failed to buffer report:", err)
+	}
+}
+
+// replayOfflineReports 连接恢复后按时间顺序补发断线期间的报告
+func replayOfflineReports(conn *ws.SafeConn) {
+	ring := getOfflineBuffer()
+	if ring == nil || !replaying.CompareAndSwap(false, true) {
+		return
+	}
+	defer replaying.Store(false)
+
+	sent, err := ring.Replay(func(entry buffer.Entry) error {
+		var report monitoring.Report
+		if err := json.Unmarshal(entry.Data, &report); err != nil {
+			log.Println("Dropping corrupt buffered report:", err)
+			return nil
+		}
+		payload := map[string]interface{}{
+			"type":      "report_backfill",
+			"timestamp": entry.Timestamp,
+			"report":    report,
+		}
+		return conn.WriteValue(payload)
+	})
+	if err != nil {
+		log.Printf("Replayed %d buffered reports before failing: %v", sent, err)
+		return
+	}
+	if sent > 0 {
+		log.Printf("Replayed %d buffered reports", sent)
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+func TestGetOfflineBufferFollowsReload(t *testing.T) {
+	old := *flags.Current()
+	defer func() {
+		flags.Update(func(c *flags.Config) { *c = old })
+		offlineBuffer, offlineConfig = nil, nil
+	}()
+	offlineBuffer, offlineConfig = nil, nil
+	dir := t.TempDir()
+
+	flags.Update(func(c *flags.Config) { c.OfflineBuffer = "" })
+	if getOfflineBuffer() != nil {
+		t.Fatal("buffer opened without --offline-buffer")
+	}
+
+	// 重新加载后启用
+	flags.Update(func(c *flags.Config) {
+		c.OfflineBuffer = filepath.Join(dir, "a.jsonl")
+		c.OfflineBufferSize, c.OfflineBufferMaxAge = 16, 24
+	})
+	first := getOfflineBuffer()
+	if first == nil {
+		t.Fatal("buffer not opened after enabling --offline-buffer")
+	}
+	if getOfflineBuffer() != first {
+		t.Error("buffer reopened without a config change")
+	}
+
+	// 只修改大小时沿用同一个缓冲区
+	flags.Update(func(c *flags.Config) { c.OfflineBufferSize = 1 })
+	if getOfflineBuffer() != first {
+		t.Error("buffer reopened when only the size changed")
+	}
+
+	flags.Update(func(c *flags.Config) { c.OfflineBuffer = filepath.Join(dir, "b.jsonl") })
+	if second := getOfflineBuffer(); second == nil || second == first {
+		t.Error("buffer not reopened after the path changed")
+	}
+
+	flags.Update(func(c *flags.Config) { c.OfflineBuffer = "" })
+	if getOfflineBuffer() != nil {
+		t.Error("buffer still enabled after clearing --offline-buffer")
+	}
+}
@@ -26,7 +26,7 @@ func NewTask(task_id, command string) {
 		uploadTaskResult(task_id, "No command provided", 0, time.Now())
 		return
 	}
-	if flags.DisableWebSsh {
+	if flags.Current().DisableWebSsh {
 		uploadTaskResult(task_id, "Remote control is disabled.", -1, time.Now())
 		return
 	}
@@ -60,6 +60,7 @@ func NewTask(task_id, command string) {
 }
 
 func uploadTaskResult(taskID, result string, exitCode int, finishedAt time.Time) {
+	cfg := flags.Current()
 	payload := map[string]interface{}{
 		"task_id":     taskID,
 		"result":      result,
@@ -68,7 +69,7 @@ func uploadTaskResult(taskID, result string, exitCode int, finishedAt time.Time)
 	}
 
 	jsonData, _ := json.Marshal(payload)
-	endpoint := flags.Endpoint + "/api/clients/task/result?token=" + flags.Token
+	endpoint := cfg.Endpoint + "/api/clients/task/result?token=" + cfg.Token
 
 	// 创建HTTP请求以支持自定义头部
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
@@ -79,14 +80,14 @@ func uploadTaskResult(taskID, result string, exitCode int, finishedAt time.Time)
 	req.Header.Set("Content-Type", "application/json")
 
 	// 添加Cloudflare Access头部（如果配置了）
-	if flags.CFAccessClientID != "" && flags.CFAccessClientSecret != "" {
-		req.Header.Set("CF-Access-Client-Id", flags.CFAccessClientID)
-		req.Header.Set("CF-Access-Client-Secret", flags.CFAccessClientSecret)
+	if cfg.CFAccessClientID != "" && cfg.CFAccessClientSecret != "" {
+		req.Header.Set("CF-Access-Client-Id", cfg.CFAccessClientID)
+		req.Header.Set("CF-Access-Client-Secret", cfg.CFAccessClientSecret)
 	}
 
-	client := &http.Client{}
+	client := HTTPClient()
 	resp, err := client.Do(req)
-	maxRetry := flags.MaxRetries
+	maxRetry := cfg.MaxRetries
 	for i := 0; i < maxRetry && (err != nil || resp.StatusCode != http.StatusOK); i++ {
 		log.Printf("Failed to upload task result, retrying %d/%d", i+1, maxRetry)
 		time.Sleep(2 * time.Second) // Wait before retrying
@@ -276,8 +277,8 @@ func NewPingTask(conn *ws.SafeConn, taskID uint, pingType, pingTarget string) {
 	//if pingResult == -1 {
 	//	return
 	//}
-	if err := conn.WriteJSON(payload); err != nil {
-		log.Printf("Failed to write JSON to WebSocket: %v", err)
+	if err := conn.WriteValue(payload); err != nil {
+		log.Printf("Failed to write ping result to WebSocket: %v", err)
 	}
 
 }
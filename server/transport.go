@@ -0,0 +1,33 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// transport 与面板通信使用的 Transport。重新加载配置时整体替换，不修改正在使用的实例。
+var transport atomic.Pointer[http.Transport]
+
+func init() {
+	transport.Store(http.DefaultTransport.(*http.Transport).Clone())
+}
+
+// ApplyTLSConfig 根据 --ignore-unsafe-cert 创建新的 Transport 并替换当前的，
+// 旧 Transport 的空闲连接会被关闭，之后的请求使用新的证书校验设置
+func ApplyTLSConfig() {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if flags.Current().IgnoreUnsafeCert {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if old := transport.Swap(t); old != nil {
+		old.CloseIdleConnections()
+	}
+}
+
+// HTTPClient 返回使用当前 TLS 设置的 HTTP 客户端
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: transport.Load()}
+}
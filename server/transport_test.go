@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+func TestApplyTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	old := flags.Current().IgnoreUnsafeCert
+	defer func() {
+		flags.Update(func(c *flags.Config) { c.IgnoreUnsafeCert = old })
+		ApplyTLSConfig()
+	}()
+
+	get := func() error {
+		resp, err := HTTPClient().Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	flags.Update(func(c *flags.Config) { c.IgnoreUnsafeCert = false })
+	ApplyTLSConfig()
+	if err := get(); err == nil {
+		t.Fatal("self-signed certificate accepted without --ignore-unsafe-cert")
+	}
+
+	flags.Update(func(c *flags.Config) { c.IgnoreUnsafeCert = true })
+	ApplyTLSConfig()
+	before := transport.Load()
+	if err := get(); err != nil {
+		t.Fatalf("request with --ignore-unsafe-cert failed: %v", err)
+	}
+
+	// 重新加载后换用新的 Transport，不修改已被其他 goroutine 使用的实例
+	flags.Update(func(c *flags.Config) { c.IgnoreUnsafeCert = false })
+	ApplyTLSConfig()
+	if transport.Load() == before || before.TLSClientConfig == nil || !before.TLSClientConfig.InsecureSkipVerify {
+		t.Error("transport was modified in place")
+	}
+	if err := get(); err == nil {
+		t.Error("kept using the insecure settings after reload")
+	}
+}
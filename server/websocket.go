@@ -2,7 +2,6 @@ package server
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"strings"
@@ -15,11 +14,46 @@ import (
 	"github.com/komari-monitor/komari-agent/ws"
 )
 
-func EstablishWebSocketConnection() {
+var (
+	// 配置热加载后分别通知上报循环与基础信息上报
+	reportConfigChanged = make(chan bool, 1)
+	infoConfigChanged   = make(chan bool, 1)
+)
+
+// NotifyConfigChanged 通知上报循环应用新的配置，reconnect 为 true 时重新建立 WebSocket 连接
+func NotifyConfigChanged(reconnect bool) {
+	notifyConfigChanged(reportConfigChanged, reconnect)
+	notifyConfigChanged(infoConfigChanged, reconnect)
+}
+
+// notifyConfigChanged 非阻塞发送，尚未处理的重连请求会与新的通知合并
+func notifyConfigChanged(ch chan bool, reconnect bool) {
+	for {
+		select {
+		case ch <- reconnect:
+			return
+		case pending := <-ch:
+			reconnect = reconnect || pending
+		}
+	}
+}
 
-	websocketEndpoint := strings.TrimSuffix(flags.Endpoint, "/") + "/api/clients/report?token=" + flags.Token
-	websocketEndpoint = "ws" + strings.TrimPrefix(websocketEndpoint, "http")
+// reportInterval 返回上报间隔，最短 1 秒。采集不阻塞，间隔即实际上报周期。
+func reportInterval() time.Duration {
+	interval := flags.Current().Interval
+	if interval < 1 {
+		interval = 1
+	}
+	return time.Duration(interval * float64(time.Second))
+}
 
+func reportEndpoint() string {
+	cfg := flags.Current()
+	websocketEndpoint := strings.TrimSuffix(cfg.Endpoint, "/") + "/api/clients/report?token=" + cfg.Token
+	return "ws" + strings.TrimPrefix(websocketEndpoint, "http")
+}
+
+func EstablishWebSocketConnection() {
 	var conn *ws.SafeConn
 	defer func() {
 		if conn != nil {
@@ -27,14 +61,10 @@ func EstablishWebSocketConnection() {
 		}
 	}()
 	var err error
-	var interval float64
-	if flags.Interval <= 1 {
-		interval = 1
-	} else {
-		interval = flags.Interval - 1
-	}
+	policy := NewReconnectPolicy()
+	retry := 0
 
-	dataTicker := time.NewTicker(time.Duration(interval * float64(time.Second)))
+	dataTicker := time.NewTicker(reportInterval())
 	defer dataTicker.Stop()
 
 	heartbeatTicker := time.NewTicker(30 * time.Second)
@@ -43,39 +73,73 @@ func EstablishWebSocketConnection() {
 	for {
 		select {
 		case <-dataTicker.C:
-			if conn == nil {
-				log.Println("Attempting to connect to WebSocket...")
-				retry := 0
-				for retry <= flags.MaxRetries {
-					if retry > 0 {
-						log.Println("Retrying websocket connection, attempt:", retry)
+			if conn == nil && backoffState.ready(time.Now()) {
+				if retry > 0 {
+					log.Println("Retrying websocket connection, attempt:", retry)
+				} else {
+					log.Println("Attempting to connect to WebSocket...")
+				}
+				conn, err = connectWebSocket(reportEndpoint())
+				if err != nil {
+					delay := backoffState.failure(policy, err, time.Now())
+					log.Printf("Failed to connect to WebSocket: %v (%s backoff, %s, waiting %s)", err, policy.Name(), backoffState.String(), delay.Round(time.Millisecond))
+					retry++
+					if flags.Current().MaxRetries >= 0 && retry > flags.Current().MaxRetries {
+						log.Println("Max retries reached.")
+						return
 					}
-					conn, err = connectWebSocket(websocketEndpoint)
-					if err == nil {
-						log.Println("WebSocket connected")
-						go handleWebSocketMessages(conn, make(chan struct{}))
-						break
+				} else {
+					if attempts := backoffState.success(); attempts > 0 {
+						log.Printf("WebSocket connected after %d failed attempts", attempts)
 					} else {
-						log.Println("Failed to connect to WebSocket:", err)
+						log.Println("WebSocket connected")
+					}
+					log.Println("Report encoding:", conn.Codec().Name)
+					retry = 0
+					go handleWebSocketMessages(conn, make(chan struct{}))
+					go replayOfflineReports(conn)
+					if err := pendingEvents.flush(conn.WriteValue); err != nil {
+						log.Println("Failed to send events:", err)
 					}
-					retry++
-					time.Sleep(time.Duration(flags.ReconnectInterval) * time.Second)
-				}
-
-				if retry > flags.MaxRetries {
-					log.Println("Max retries reached.")
-					return
 				}
 			}
+			// 断线且未启用缓冲时无需采集
+			if conn == nil && getOfflineBuffer() == nil {
+				continue
+			}
 
-			data := monitoring.GenerateReport()
-			err = conn.WriteMessage(websocket.TextMessage, data)
+			sampledAt := time.Now()
+			report := monitoring.CollectReport()
+			if conn == nil {
+				bufferReport(sampledAt, report)
+				continue
+			}
+			err = conn.WriteValue(report)
 			if err != nil {
 				log.Println("Failed to send WebSocket message:", err)
+				bufferReport(sampledAt, report)
 				conn.Close()
 				conn = nil // Mark connection as dead
 				continue
 			}
+		case ev := <-monitoring.Events():
+			if conn == nil {
+				pendingEvents.push(ev)
+				continue
+			}
+			if err := sendEvent(conn, ev); err != nil {
+				log.Println("Failed to send event:", err)
+				conn.Close()
+				conn = nil // Mark connection as dead
+			}
+		case reconnect := <-reportConfigChanged:
+			dataTicker.Reset(reportInterval())
+			policy = NewReconnectPolicy()
+			if reconnect && conn != nil {
+				log.Println("Connection settings changed, reconnecting WebSocket...")
+				conn.Close()
+				conn = nil
+			}
 		case <-heartbeatTicker.C:
 			if conn != nil {
 				err := conn.WriteMessage(websocket.PingMessage, nil)
@@ -90,24 +154,30 @@ func EstablishWebSocketConnection() {
 }
 
 func connectWebSocket(websocketEndpoint string) (*ws.SafeConn, error) {
+	cfg := flags.Current()
 	dialer := &websocket.Dialer{
-		HandshakeTimeout: 5 * time.Second,
+		HandshakeTimeout:  5 * time.Second,
+		Subprotocols:      ws.Subprotocols(cfg.ReportEncoding),
+		EnableCompression: cfg.WsCompression,
 	}
 	
 	// 创建请求头并添加Cloudflare Access头部
 	headers := http.Header{}
-	if flags.CFAccessClientID != "" && flags.CFAccessClientSecret != "" {
-		headers.Set("CF-Access-Client-Id", flags.CFAccessClientID)
-		headers.Set("CF-Access-Client-Secret", flags.CFAccessClientSecret)
+	if cfg.CFAccessClientID != "" && cfg.CFAccessClientSecret != "" {
+		headers.Set("CF-Access-Client-Id", cfg.CFAccessClientID)
+		headers.Set("CF-Access-Client-Secret", cfg.CFAccessClientSecret)
 	}
 	
 	conn, resp, err := dialer.Dial(websocketEndpoint, headers)
 	if err != nil {
 		if resp != nil && resp.StatusCode != 101 {
-			return nil, fmt.Errorf("%s", resp.Status)
+			return nil, newHandshakeError(resp)
 		}
 		return nil, err
 	}
+	if cfg.WsCompression && !strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate") {
+		log.Println("Server does not support permessage-deflate, sending uncompressed")
+	}
 
 	return ws.NewSafeConn(conn), nil
 }
@@ -139,7 +209,7 @@ func handleWebSocketMessages(conn *ws.SafeConn, done chan<- struct{}) {
 		}
 
 		if message.Message == "terminal" || message.TerminalId != "" {
-			go establishTerminalConnection(flags.Token, message.TerminalId, flags.Endpoint)
+			go establishTerminalConnection(flags.Current().Token, message.TerminalId, flags.Current().Endpoint)
 			continue
 		}
 		if message.Message == "exec" {
@@ -157,6 +227,7 @@ func handleWebSocketMessages(conn *ws.SafeConn, done chan<- struct{}) {
 
 // establishTerminalConnection 建立终端连接并使用terminal包处理终端操作
 func establishTerminalConnection(token, id, endpoint string) {
+	cfg := flags.Current()
 	endpoint = strings.TrimSuffix(endpoint, "/") + "/api/clients/terminal?token=" + token + "&id=" + id
 	endpoint = "ws" + strings.TrimPrefix(endpoint, "http")
 	dialer := &websocket.Dialer{
@@ -165,9 +236,9 @@ func establishTerminalConnection(token, id, endpoint string) {
 	
 	// 创建请求头并添加Cloudflare Access头部
 	headers := http.Header{}
-	if flags.CFAccessClientID != "" && flags.CFAccessClientSecret != "" {
-		headers.Set("CF-Access-Client-Id", flags.CFAccessClientID)
-		headers.Set("CF-Access-Client-Secret", flags.CFAccessClientSecret)
+	if cfg.CFAccessClientID != "" && cfg.CFAccessClientSecret != "" {
+		headers.Set("CF-Access-Client-Id", cfg.CFAccessClientID)
+		headers.Set("CF-Access-Client-Secret", cfg.CFAccessClientSecret)
 	}
 	
 	conn, _, err := dialer.Dial(endpoint, headers)
@@ -26,7 +26,7 @@ type terminalImpl struct {
 
 // StartTerminal 启动终端并处理 WebSocket 通信
 func StartTerminal(conn *websocket.Conn) {
-	if flags.DisableWebSsh {
+	if flags.Current().DisableWebSsh {
 		conn.WriteMessage(websocket.TextMessage, []byte("\n\nWeb SSH is disabled. Enable it by running without the --disable-web-ssh flag."))
 		conn.Close()
 		return
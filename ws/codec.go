@@ -0,0 +1,86 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec 发送给服务端的消息所使用的编码，通过 WebSocket 子协议协商。
+// 二进制编码沿用结构体的 json 标签作为字段名。
+type Codec struct {
+	Name        string
+	Subprotocol string
+	MessageType int
+	Marshal     func(v interface{}) ([]byte, error)
+}
+
+var (
+	JSONCodec = Codec{
+		Name:        "json",
+		Subprotocol: "komari.json",
+		MessageType: websocket.TextMessage,
+		Marshal:     json.Marshal,
+	}
+	MsgpackCodec = Codec{
+		Name:        "msgpack",
+		Subprotocol: "komari.msgpack",
+		MessageType: websocket.BinaryMessage,
+		Marshal:     marshalMsgpack,
+	}
+	CBORCodec = Codec{
+		Name:        "cbor",
+		Subprotocol: "komari.cbor",
+		MessageType: websocket.BinaryMessage,
+		Marshal:     cbor.Marshal,
+	}
+)
+
+// codecs 按自动协商时的优先级排列
+var codecs = []Codec{MsgpackCodec, CBORCodec, JSONCodec}
+
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Subprotocols 返回握手时提供的子协议列表。
+// encoding 为 auto 时按优先级提供全部编码；为 json 时不提供子协议，与旧版行为一致；
+// 指定其他编码时同时提供 JSON 作为回退。
+func Subprotocols(encoding string) []string {
+	switch encoding {
+	case "", JSONCodec.Name:
+		return nil
+	case "auto":
+		protocols := make([]string, 0, len(codecs))
+		for _, c := range codecs {
+			protocols = append(protocols, c.Subprotocol)
+		}
+		return protocols
+	}
+	for _, c := range codecs {
+		if c.Name == encoding {
+			return []string{c.Subprotocol, JSONCodec.Subprotocol}
+		}
+	}
+	return nil
+}
+
+// CodecForSubprotocol 返回服务端选定的子协议对应的编码，服务端未选择时回退到 JSON
+func CodecForSubprotocol(protocol string) Codec {
+	for _, c := range codecs {
+		if c.Subprotocol == protocol {
+			return c
+		}
+	}
+	return JSONCodec
+}
@@ -0,0 +1,76 @@
+package ws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type sample struct {
+	Usage  float64 `json:"usage"`
+	TCP    int     `json:"tcp"`
+	Absent string  `json:"absent,omitempty"`
+}
+
+func TestBinaryCodecsUseJSONNames(t *testing.T) {
+	v := sample{Usage: 12.5, TCP: 3}
+
+	data, err := MsgpackCodec.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromMsgpack map[string]interface{}
+	if err := msgpack.Unmarshal(data, &fromMsgpack); err != nil {
+		t.Fatal(err)
+	}
+	if len(fromMsgpack) != 2 || fromMsgpack["usage"] != 12.5 {
+		t.Errorf("msgpack decoded %v", fromMsgpack)
+	}
+
+	data, err = CBORCodec.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromCBOR map[string]interface{}
+	if err := cbor.Unmarshal(data, &fromCBOR); err != nil {
+		t.Fatal(err)
+	}
+	if len(fromCBOR) != 2 || fromCBOR["usage"] != 12.5 {
+		t.Errorf("cbor decoded %v", fromCBOR)
+	}
+
+	if MsgpackCodec.MessageType != websocket.BinaryMessage || JSONCodec.MessageType != websocket.TextMessage {
+		t.Error("unexpected message types")
+	}
+}
+
+func TestSubprotocols(t *testing.T) {
+	tests := []struct {
+		encoding string
+		want     []string
+	}{
+		{"json", nil},
+		{"", nil},
+		{"auto", []string{"komari.msgpack", "komari.cbor", "komari.json"}},
+		{"cbor", []string{"komari.cbor", "komari.json"}},
+		{"bogus", nil},
+	}
+	for _, tt := range tests {
+		if got := Subprotocols(tt.encoding); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Subprotocols(%q) = %v, want %v", tt.encoding, got, tt.want)
+		}
+	}
+}
+
+func TestCodecForSubprotocol(t *testing.T) {
+	if c := CodecForSubprotocol("komari.msgpack"); c.Name != "msgpack" {
+		t.Errorf("got %s, want msgpack", c.Name)
+	}
+	// 服务端未选择子协议时回退到 JSON
+	if c := CodecForSubprotocol(""); c.Name != "json" {
+		t.Errorf("got %s, want json", c.Name)
+	}
+}
@@ -8,17 +8,33 @@ import (
 )
 
 type SafeConn struct {
-	conn *websocket.Conn
-	mu   sync.Mutex
+	conn  *websocket.Conn
+	mu    sync.Mutex
+	codec Codec
 }
 
 func NewSafeConn(conn *websocket.Conn) *SafeConn {
 	return &SafeConn{
-		conn: conn,
-		mu:   sync.Mutex{},
+		conn:  conn,
+		mu:    sync.Mutex{},
+		codec: CodecForSubprotocol(conn.Subprotocol()),
 	}
 }
 
+// Codec 返回握手时协商得到的编码
+func (sc *SafeConn) Codec() Codec {
+	return sc.codec
+}
+
+// WriteValue 使用协商得到的编码发送 v
+func (sc *SafeConn) WriteValue(v interface{}) error {
+	data, err := sc.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return sc.WriteMessage(sc.codec.MessageType, data)
+}
+
 func (sc *SafeConn) WriteMessage(messageType int, data []byte) error {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()